@@ -0,0 +1,143 @@
+// configuration/config.go
+// Config is the sidecar's layered configuration. Load builds it from, in
+// increasing order of precedence: built-in defaults, an optional YAML file
+// (-config), environment variables (via kelseyhightower/envconfig, prefixed
+// ICAHT_), and CLI flags. Manager (see reload.go) additionally supports
+// re-loading this same layering on SIGHUP without restarting the process;
+// each field's doc comment notes whether it's picked up by that reload.
+
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is prepended to every Config field's envconfig tag, so e.g.
+// RegistrationPort is read from ICAHT_REGISTRATION_PORT.
+const envPrefix = "ICAHT"
+
+// Config holds every tunable the sidecar reads at startup.
+type Config struct {
+	// RegistrationPort is the port the registration/heartbeat/discovery API
+	// listens on. Not reloadable: the listener isn't restarted on SIGHUP.
+	RegistrationPort int `yaml:"registration_port" envconfig:"REGISTRATION_PORT"`
+
+	// ProxyPortRangeStart is the first port ProxyManager allocates proxy
+	// listeners from. Reloadable: applies to ports allocated after the
+	// reload; already-running proxies keep the port they have.
+	ProxyPortRangeStart int `yaml:"proxy_port_range_start" envconfig:"PROXY_PORT_RANGE_START"`
+
+	// HeartbeatScanInterval is how often MemoryRegistry's expiry scanner
+	// checks for lapsed leases. Not reloadable: StartExpiryScanner's ticker
+	// isn't recreated on SIGHUP.
+	HeartbeatScanInterval time.Duration `yaml:"heartbeat_scan_interval" envconfig:"HEARTBEAT_SCAN_INTERVAL"`
+
+	// ShutdownTimeout bounds how long graceful shutdown waits for
+	// connections to drain, for both the registration server and
+	// ProxyManager. Reloadable: applies to shutdowns started after the
+	// reload.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" envconfig:"SHUTDOWN_TIMEOUT"`
+
+	// DefaultHealthCheckInterval is the active-probe interval used when a
+	// registration's health_check omits interval_seconds. Reloadable: main
+	// wires it to HealthChecker.SetDefaultInterval, so it only affects
+	// checks started after the reload; a check already running keeps the
+	// interval it started with.
+	DefaultHealthCheckInterval time.Duration `yaml:"default_health_check_interval" envconfig:"DEFAULT_HEALTH_CHECK_INTERVAL"`
+
+	// LogLevel is one of "debug", "info", "warn", "error"; only "debug" is
+	// currently distinguished from the rest, via core.SetLogLevel gating
+	// core.Debugf. Reloadable: main wires it to core.SetLogLevel.
+	LogLevel string `yaml:"log_level" envconfig:"LOG_LEVEL"`
+
+	// DiagnosticsBindAddress is the bind address the diagnostics listener
+	// (/healthz, /readyz, /metrics, /debug/pprof, /v1/proxies) binds to.
+	// Not reloadable: the listener isn't restarted on SIGHUP. Defaults to
+	// loopback-only since pprof profiling is sensitive to expose broadly.
+	DiagnosticsBindAddress string `yaml:"diagnostics_bind_address" envconfig:"DIAGNOSTICS_BIND_ADDRESS"`
+
+	// DiagnosticsPort is the port the diagnostics listener binds to. Not
+	// reloadable.
+	DiagnosticsPort int `yaml:"diagnostics_port" envconfig:"DIAGNOSTICS_PORT"`
+}
+
+// defaultConfig returns Config's built-in defaults, the base layer Load
+// starts from. These are plain assignments rather than envconfig "default"
+// tags: envconfig applies a default tag's value whenever its env var is
+// unset, which would silently clobber a value the YAML layer had already
+// set, defeating the point of layering.
+func defaultConfig() *Config {
+	return &Config{
+		RegistrationPort:           8500,
+		ProxyPortRangeStart:        20000,
+		HeartbeatScanInterval:      5 * time.Second,
+		ShutdownTimeout:            10 * time.Second,
+		DefaultHealthCheckInterval: 10 * time.Second,
+		LogLevel:                   "info",
+		DiagnosticsBindAddress:     "127.0.0.1",
+		DiagnosticsPort:            8501,
+	}
+}
+
+// Validate checks that cfg is safe to run with: port ranges in bounds,
+// durations positive, and LogLevel one of the values the sidecar recognizes.
+func (c *Config) Validate() error {
+	if c.RegistrationPort <= 0 || c.RegistrationPort > 65535 {
+		return fmt.Errorf("configuration: registration_port must be between 1 and 65535, got %d", c.RegistrationPort)
+	}
+	if c.ProxyPortRangeStart <= 0 || c.ProxyPortRangeStart > 65535 {
+		return fmt.Errorf("configuration: proxy_port_range_start must be between 1 and 65535, got %d", c.ProxyPortRangeStart)
+	}
+	if c.HeartbeatScanInterval <= 0 {
+		return fmt.Errorf("configuration: heartbeat_scan_interval must be positive, got %s", c.HeartbeatScanInterval)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("configuration: shutdown_timeout must be positive, got %s", c.ShutdownTimeout)
+	}
+	if c.DefaultHealthCheckInterval <= 0 {
+		return fmt.Errorf("configuration: default_health_check_interval must be positive, got %s", c.DefaultHealthCheckInterval)
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("configuration: log_level must be one of debug, info, warn, error, got %q", c.LogLevel)
+	}
+	if c.DiagnosticsPort <= 0 || c.DiagnosticsPort > 65535 {
+		return fmt.Errorf("configuration: diagnostics_port must be between 1 and 65535, got %d", c.DiagnosticsPort)
+	}
+	if c.DiagnosticsBindAddress == "" {
+		return fmt.Errorf("configuration: diagnostics_bind_address must not be empty")
+	}
+	return nil
+}
+
+// applyFile overlays path's YAML document onto cfg. Only keys present in
+// the file are touched, so it composes with defaultConfig as a sparse
+// override rather than a full replacement. HCL isn't supported yet; add a
+// second unmarshal path here the same way once a parser dependency is
+// picked.
+func applyFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("configuration: reading config file %q: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("configuration: parsing config file %q: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays environment variables prefixed ICAHT_ onto cfg. Fields
+// whose env var is unset are left as they are, so this composes with
+// whatever applyFile already set.
+func applyEnv(cfg *Config) error {
+	if err := envconfig.Process(envPrefix, cfg); err != nil {
+		return fmt.Errorf("configuration: applying environment overrides: %w", err)
+	}
+	return nil
+}
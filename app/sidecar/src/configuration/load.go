@@ -0,0 +1,74 @@
+// configuration/load.go
+// Load wires Config's layers together: defaults, an optional -config YAML
+// file, ICAHT_-prefixed environment variables, then CLI flags, in that
+// order of increasing precedence.
+
+package configuration
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Load parses args (typically os.Args[1:]) and returns the resulting
+// Config, or an error if a layer fails to apply or the result fails
+// Validate. args is also what Manager.Reload re-parses on SIGHUP, so a
+// flag given on the command line keeps overriding the file/env layers
+// across reloads.
+func Load(args []string) (*Config, error) {
+	cfg := defaultConfig()
+
+	fs := flag.NewFlagSet("icaht-sidecar", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to a YAML config file (optional)")
+	registrationPort := fs.Int("registration-port", 0, "Port the registration API listens on")
+	proxyPortRangeStart := fs.Int("proxy-port-range-start", 0, "First port allocated to a service's proxy")
+	heartbeatScanInterval := fs.Duration("heartbeat-scan-interval", 0, "How often to evict services with a lapsed lease")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 0, "Drain timeout for graceful shutdown")
+	defaultHealthCheckInterval := fs.Duration("default-health-check-interval", 0, "Default active-probe interval when a registration omits one")
+	logLevel := fs.String("log-level", "", "One of debug, info, warn, error")
+	diagnosticsBindAddress := fs.String("diagnostics-bind-address", "", "Bind address for the diagnostics listener")
+	diagnosticsPort := fs.Int("diagnostics-port", 0, "Port for the diagnostics listener (/healthz, /readyz, /metrics, /debug/pprof, /v1/proxies)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("configuration: parsing flags: %w", err)
+	}
+
+	if *configPath != "" {
+		if err := applyFile(cfg, *configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	// Only overrides explicitly passed on the command line take effect
+	// here; fs.Visit skips flags left at their zero-value default, so an
+	// unset flag never clobbers what the file/env layers already set.
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "registration-port":
+			cfg.RegistrationPort = *registrationPort
+		case "proxy-port-range-start":
+			cfg.ProxyPortRangeStart = *proxyPortRangeStart
+		case "heartbeat-scan-interval":
+			cfg.HeartbeatScanInterval = *heartbeatScanInterval
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = *shutdownTimeout
+		case "default-health-check-interval":
+			cfg.DefaultHealthCheckInterval = *defaultHealthCheckInterval
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		case "diagnostics-bind-address":
+			cfg.DiagnosticsBindAddress = *diagnosticsBindAddress
+		case "diagnostics-port":
+			cfg.DiagnosticsPort = *diagnosticsPort
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
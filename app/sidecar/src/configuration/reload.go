@@ -0,0 +1,149 @@
+// configuration/reload.go
+// Manager owns the currently effective Config and re-runs Load's layering
+// whenever the process receives SIGHUP, logging a diff of what changed and
+// notifying any registered hooks so callers can re-apply the fields that
+// don't require a restart (see each Config field's doc comment for which
+// ones those are).
+
+package configuration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ReloadHook is invoked after a successful Reload with the config before
+// and after the swap. Hooks run synchronously from the SIGHUP handler, so
+// they should return quickly.
+type ReloadHook func(previous, next Config)
+
+// Manager makes a Config loaded once at startup reloadable.
+type Manager struct {
+	args []string // Re-parsed on every Reload, so CLI overrides survive it.
+
+	mu  sync.RWMutex
+	cfg *Config
+
+	hookMu sync.Mutex
+	hooks  []ReloadHook
+}
+
+// NewManager wraps cfg (typically the result of Load(args)) for reload
+// purposes.
+func NewManager(cfg *Config, args []string) *Manager {
+	return &Manager{cfg: cfg, args: args}
+}
+
+// Current returns the currently effective config. Callers that act on a
+// field across reloads should call this each time rather than caching the
+// result.
+func (m *Manager) Current() Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return *m.cfg
+}
+
+// OnReload registers a hook to run after every successful Reload. Typical
+// use is wiring a reloadable field to the live component it configures,
+// e.g. ProxyManager.SetPortRangeStart for ProxyPortRangeStart.
+func (m *Manager) OnReload(hook ReloadHook) {
+	m.hookMu.Lock()
+	defer m.hookMu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Reload re-runs Load(m.args), swaps in the result if it validates, logs
+// what changed, and runs every registered hook. The previous config is
+// kept if Load fails, so a bad edit to the config file doesn't take down
+// an already-running sidecar.
+func (m *Manager) Reload() error {
+	next, err := Load(m.args)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	m.mu.Lock()
+	previous := *m.cfg
+	m.cfg = next
+	m.mu.Unlock()
+
+	for _, line := range diff(previous, *next) {
+		log.Printf("Config reload: %s", line)
+	}
+
+	m.hookMu.Lock()
+	hooks := append([]ReloadHook(nil), m.hooks...)
+	m.hookMu.Unlock()
+	for _, hook := range hooks {
+		hook(previous, *next)
+	}
+	return nil
+}
+
+// WatchSIGHUP reloads on every SIGHUP the process receives until ctx is
+// done. Callers should run it in a goroutine.
+func (m *Manager) WatchSIGHUP(ctx context.Context) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			if err := m.Reload(); err != nil {
+				log.Printf("Config reload failed, keeping previous config: %v", err)
+			}
+		}
+	}
+}
+
+// DebugHandler serves the currently effective config as JSON, for mounting
+// on an operator-facing /debug/config endpoint.
+func (m *Manager) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m.Current()); err != nil {
+			log.Printf("Failed to encode /debug/config response: %v", err)
+		}
+	})
+}
+
+// diff lists human-readable "field: old -> new" lines for every field that
+// differs between a and b.
+func diff(a, b Config) []string {
+	var lines []string
+	if a.RegistrationPort != b.RegistrationPort {
+		lines = append(lines, fmt.Sprintf("registration_port: %d -> %d (requires a restart to take effect)", a.RegistrationPort, b.RegistrationPort))
+	}
+	if a.ProxyPortRangeStart != b.ProxyPortRangeStart {
+		lines = append(lines, fmt.Sprintf("proxy_port_range_start: %d -> %d", a.ProxyPortRangeStart, b.ProxyPortRangeStart))
+	}
+	if a.HeartbeatScanInterval != b.HeartbeatScanInterval {
+		lines = append(lines, fmt.Sprintf("heartbeat_scan_interval: %s -> %s (requires a restart to take effect)", a.HeartbeatScanInterval, b.HeartbeatScanInterval))
+	}
+	if a.ShutdownTimeout != b.ShutdownTimeout {
+		lines = append(lines, fmt.Sprintf("shutdown_timeout: %s -> %s", a.ShutdownTimeout, b.ShutdownTimeout))
+	}
+	if a.DefaultHealthCheckInterval != b.DefaultHealthCheckInterval {
+		lines = append(lines, fmt.Sprintf("default_health_check_interval: %s -> %s", a.DefaultHealthCheckInterval, b.DefaultHealthCheckInterval))
+	}
+	if a.LogLevel != b.LogLevel {
+		lines = append(lines, fmt.Sprintf("log_level: %s -> %s", a.LogLevel, b.LogLevel))
+	}
+	if a.DiagnosticsBindAddress != b.DiagnosticsBindAddress {
+		lines = append(lines, fmt.Sprintf("diagnostics_bind_address: %s -> %s (requires a restart to take effect)", a.DiagnosticsBindAddress, b.DiagnosticsBindAddress))
+	}
+	if a.DiagnosticsPort != b.DiagnosticsPort {
+		lines = append(lines, fmt.Sprintf("diagnostics_port: %d -> %d (requires a restart to take effect)", a.DiagnosticsPort, b.DiagnosticsPort))
+	}
+	return lines
+}
@@ -0,0 +1,83 @@
+// core/diagnostics.go
+// DiagnosticsServer exposes the sidecar's own operational state — liveness,
+// readiness, Prometheus metrics, Go's pprof profiles, and a snapshot of
+// every running proxy — on a listener separate from the registration API,
+// analogous to the diagnostic/admin port pattern used by Consul's connect
+// proxy and Teleport. Keeping it separate means it stays reachable for
+// post-mortem profiling even if the registration API itself is overloaded
+// or misbehaving, and operators can bind it to loopback-only without
+// affecting registration traffic. Requires
+// github.com/prometheus/client_golang/prometheus/promhttp as a dependency,
+// alongside the prometheus client already used by core/middleware.
+
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DiagnosticsServer serves /healthz, /readyz, /metrics, /debug/pprof/*, and
+// /v1/proxies.
+type DiagnosticsServer struct {
+	proxyManager *ProxyManager
+}
+
+// NewDiagnosticsServer creates a new DiagnosticsServer.
+func NewDiagnosticsServer(proxyManager *ProxyManager) *DiagnosticsServer {
+	return &DiagnosticsServer{proxyManager: proxyManager}
+}
+
+// Handler returns the http.Handler to serve, typically on its own listener
+// bound to cfg.DiagnosticsBindAddress:cfg.DiagnosticsPort.
+func (d *DiagnosticsServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", d.healthz)
+	mux.HandleFunc("/readyz", d.readyz)
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/v1/proxies", d.proxies)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
+// healthz reports whether the process is up at all, with no dependency
+// checks: a sidecar that can't answer this has crashed or deadlocked.
+func (d *DiagnosticsServer) healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// readyz reports whether the sidecar is ready to take registration
+// traffic. Nothing it depends on today can be independently unready
+// (MemoryRegistry has no connection to fail, and the remote backends'
+// clients already surface connection errors per-call rather than going
+// into some ready/not-ready state), so this is equivalent to healthz for
+// now; it's kept as a separate endpoint so a future backend-specific check
+// has somewhere to plug in without callers needing to know which endpoint
+// means what.
+func (d *DiagnosticsServer) readyz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// proxies serves GET /v1/proxies: a JSON snapshot of every running proxy's
+// key, upstream, port, uptime, and request count.
+func (d *DiagnosticsServer) proxies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.proxyManager.Snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,247 @@
+// core/discovery_handler.go
+// This file defines the HTTP handler for service discovery: the read side
+// of the registration pattern RegistrationHandler writes to. Clients use it
+// to resolve a service's nodes (optionally filtered by metadata) without
+// knowing its proxy port up front, and to watch for topology changes.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// watchLongPollTimeout bounds how long a long-poll /watch request waits for
+// the next event before returning 204 No Content so the client can re-poll.
+const watchLongPollTimeout = 30 * time.Second
+
+// DiscoveryHandler serves read-only service discovery queries against a
+// RegistryStore: GET /services, GET /services/{name}, GET
+// /services/{name}/{version}, and GET /services/{name}/watch (or
+// /services/{name}/{version}/watch) for streaming changes.
+type DiscoveryHandler struct {
+	registry RegistryStore
+}
+
+// NewDiscoveryHandler creates a new DiscoveryHandler.
+func NewDiscoveryHandler(registry RegistryStore) *DiscoveryHandler {
+	return &DiscoveryHandler{registry: registry}
+}
+
+// ServeHTTP implements the http.Handler interface, routing based on the
+// path segments under /services.
+func (h *DiscoveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := pathSegments(r.URL.Path, "/services")
+	switch len(segments) {
+	case 0:
+		h.listAll(w, r)
+	case 1:
+		h.listByName(w, r, segments[0])
+	case 2:
+		if segments[1] == "watch" {
+			h.watch(w, r, segments[0], r.URL.Query().Get("version"))
+			return
+		}
+		h.listByNameVersion(w, r, segments[0], segments[1])
+	case 3:
+		if segments[2] == "watch" {
+			h.watch(w, r, segments[0], segments[1])
+			return
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// pathSegments strips prefix from path and splits what remains on "/",
+// dropping empty segments so both "/services" and "/services/" route the
+// same way.
+func pathSegments(path, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// listAll serves GET /services: every registered node, grouped by
+// "name-version", filtered by any meta.* query parameters.
+func (h *DiscoveryHandler) listAll(w http.ResponseWriter, r *http.Request) {
+	filters := metadataFilters(r.URL.Query())
+	services := h.registry.List()
+
+	filtered := make(map[string][]*ServiceInfo, len(services))
+	for key, nodes := range services {
+		if matches := filterByMetadata(nodes, filters); len(matches) > 0 {
+			filtered[key] = matches
+		}
+	}
+	writeJSON(w, filtered)
+}
+
+// listByName serves GET /services/{name}: every node across all versions of
+// name, filtered by any meta.* query parameters.
+func (h *DiscoveryHandler) listByName(w http.ResponseWriter, r *http.Request, name string) {
+	filters := metadataFilters(r.URL.Query())
+
+	var nodes []*ServiceInfo
+	for _, group := range h.registry.List() {
+		for _, node := range group {
+			if node.Name == name {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	nodes = filterByMetadata(nodes, filters)
+	if len(nodes) == 0 {
+		http.Error(w, fmt.Sprintf("no nodes found for service '%s'", name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, nodes)
+}
+
+// listByNameVersion serves GET /services/{name}/{version}: every node for
+// that exact name-version, filtered by any meta.* query parameters.
+func (h *DiscoveryHandler) listByNameVersion(w http.ResponseWriter, r *http.Request, name, version string) {
+	nodes, ok := h.registry.Get(name, version)
+	if !ok {
+		http.Error(w, fmt.Sprintf("service '%s-%s' not found", name, version), http.StatusNotFound)
+		return
+	}
+	nodes = filterByMetadata(nodes, metadataFilters(r.URL.Query()))
+	if len(nodes) == 0 {
+		http.Error(w, fmt.Sprintf("no nodes for service '%s-%s' match the given filters", name, version), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, nodes)
+}
+
+// watch serves GET /services/{name}/watch and GET
+// /services/{name}/{version}/watch, streaming Create/Update/Delete events
+// as Server-Sent Events when the client sends "Accept: text/event-stream",
+// or as a single long-polled JSON event otherwise. version must be supplied
+// via the {version} path segment or a ?version= query parameter.
+func (h *DiscoveryHandler) watch(w http.ResponseWriter, r *http.Request, name, version string) {
+	if version == "" {
+		http.Error(w, "a version is required to watch a service (path segment or ?version=)", http.StatusBadRequest)
+		return
+	}
+
+	events, unsubscribe := h.registry.Watch(name, version)
+	defer unsubscribe()
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.watchSSE(w, r, events)
+		return
+	}
+	h.watchLongPoll(w, r, events)
+}
+
+// watchSSE streams events as Server-Sent Events until the client
+// disconnects.
+func (h *DiscoveryHandler) watchSSE(w http.ResponseWriter, r *http.Request, events <-chan Event) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// watchLongPoll waits for a single event (or watchLongPollTimeout) and
+// responds once, so clients poll in a loop rather than holding a streaming
+// connection open.
+func (h *DiscoveryHandler) watchLongPoll(w http.ResponseWriter, r *http.Request, events <-chan Event) {
+	select {
+	case <-r.Context().Done():
+		return
+	case event, ok := <-events:
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, event)
+	case <-time.After(watchLongPollTimeout):
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// metadataFilters extracts "meta.<key>=<value>" query parameters into a
+// plain key/value map for filterByMetadata.
+func metadataFilters(query map[string][]string) map[string]string {
+	const prefix = "meta."
+	filters := make(map[string]string)
+	for key, values := range query {
+		if !strings.HasPrefix(key, prefix) || len(values) == 0 {
+			continue
+		}
+		filters[strings.TrimPrefix(key, prefix)] = values[0]
+	}
+	return filters
+}
+
+// filterByMetadata returns the subset of nodes whose Metadata matches every
+// key/value pair in filters. An empty filters map matches everything.
+func filterByMetadata(nodes []*ServiceInfo, filters map[string]string) []*ServiceInfo {
+	if len(filters) == 0 {
+		return nodes
+	}
+
+	var matches []*ServiceInfo
+	for _, node := range nodes {
+		if nodeMatchesMetadata(node, filters) {
+			matches = append(matches, node)
+		}
+	}
+	return matches
+}
+
+func nodeMatchesMetadata(node *ServiceInfo, filters map[string]string) bool {
+	for key, want := range filters {
+		if node.Metadata[key] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// writeJSON encodes v as the JSON response body, logging (rather than
+// failing) if encoding fails after headers may already be written.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode discovery response: %v", err)
+	}
+}
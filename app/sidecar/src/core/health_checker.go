@@ -0,0 +1,300 @@
+// core/health_checker.go
+// This file defines HealthChecker, which actively probes a node's Endpoint
+// on an interval after it registers, tracks Passing/Warning/Critical state
+// on its ServiceInfo, and deregisters the node (stopping its proxy if it
+// was the last one) once it fails too many checks in a row. This is
+// separate from the TTL/heartbeat lease mechanism in registry.go: a node
+// can have one, both, or neither watching it.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// CheckType selects the protocol HealthChecker uses to probe a node.
+type CheckType string
+
+const (
+	// CheckHTTP expects a 2xx response from Path (default "/").
+	CheckHTTP CheckType = "http"
+	// CheckTCP expects a TCP dial to the endpoint to succeed.
+	CheckTCP CheckType = "tcp"
+	// CheckGRPC calls the grpc.health.v1.Health/Check RPC and expects SERVING.
+	CheckGRPC CheckType = "grpc"
+)
+
+// defaultHealthCheckInterval is the fallback HealthCheckSpec.withDefaults
+// uses when no other default interval applies, e.g. ProxyManager's
+// WithEndpointHealthCheck option, which isn't wired to configuration.Config.
+const defaultHealthCheckInterval = 10 * time.Second
+
+// HealthCheckSpec configures an active health check for a single node.
+type HealthCheckSpec struct {
+	Type                   CheckType     // Defaults to CheckHTTP.
+	Path                   string        // HTTP check path; defaults to "/".
+	Interval               time.Duration // Time between probes; defaults to HealthChecker's configured default interval.
+	Timeout                time.Duration // Per-probe timeout; defaults to Interval/2.
+	FailuresBeforeCritical int           // Consecutive failures before eviction; defaults to 3.
+}
+
+// withDefaults fills in spec's zero-valued fields, using defaultInterval
+// (HealthChecker's configured default, normally cfg.DefaultHealthCheckInterval)
+// for Interval when a registration didn't specify its own.
+func (spec HealthCheckSpec) withDefaults(defaultInterval time.Duration) HealthCheckSpec {
+	if spec.Type == "" {
+		spec.Type = CheckHTTP
+	}
+	if spec.Path == "" {
+		spec.Path = "/"
+	}
+	if spec.Interval <= 0 {
+		spec.Interval = defaultInterval
+	}
+	if spec.Timeout <= 0 {
+		spec.Timeout = spec.Interval / 2
+	}
+	if spec.FailuresBeforeCritical <= 0 {
+		spec.FailuresBeforeCritical = 3
+	}
+	return spec
+}
+
+// HealthChecker runs one probing goroutine per watched node.
+type HealthChecker struct {
+	store        RegistryStore
+	proxyManager *ProxyManager
+
+	mu              sync.Mutex
+	cancels         map[string]context.CancelFunc // Keyed by name-version-nodeID.
+	defaultInterval time.Duration                 // Used by Start for a spec that omits Interval; kept up to date by SetDefaultInterval.
+}
+
+// NewHealthChecker creates a HealthChecker that deregisters failed nodes
+// from store and stops their proxy via proxyManager once none are left.
+// defaultInterval is used by Start for a registration whose HealthCheckSpec
+// doesn't set Interval; it's normally cfg.DefaultHealthCheckInterval.
+func NewHealthChecker(store RegistryStore, proxyManager *ProxyManager, defaultInterval time.Duration) *HealthChecker {
+	return &HealthChecker{
+		store:           store,
+		proxyManager:    proxyManager,
+		cancels:         make(map[string]context.CancelFunc),
+		defaultInterval: defaultInterval,
+	}
+}
+
+// SetDefaultInterval changes the interval Start falls back to for specs
+// that omit one. It only affects checks started after the call; a check
+// already running keeps the interval it started with.
+func (hc *HealthChecker) SetDefaultInterval(interval time.Duration) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.defaultInterval = interval
+}
+
+func checkKey(name, version, nodeID string) string {
+	return fmt.Sprintf("%s-%s", serviceKey(name, version), nodeID)
+}
+
+// Start begins probing node per spec. If a check is already running for
+// this node, it is stopped and replaced, so re-registering with a new spec
+// takes effect immediately.
+func (hc *HealthChecker) Start(node *ServiceInfo, spec HealthCheckSpec) {
+	hc.mu.Lock()
+	defaultInterval := hc.defaultInterval
+	hc.mu.Unlock()
+
+	spec = spec.withDefaults(defaultInterval)
+	hc.Stop(node.Name, node.Version, node.NodeID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hc.mu.Lock()
+	hc.cancels[checkKey(node.Name, node.Version, node.NodeID)] = cancel
+	hc.mu.Unlock()
+
+	go hc.run(ctx, node, spec)
+}
+
+// Stop cancels any running check for the given node. Safe to call even if
+// no check is running.
+func (hc *HealthChecker) Stop(name, version, nodeID string) {
+	key := checkKey(name, version, nodeID)
+	hc.mu.Lock()
+	cancel, exists := hc.cancels[key]
+	delete(hc.cancels, key)
+	hc.mu.Unlock()
+
+	if exists {
+		cancel()
+	}
+}
+
+// StopAll cancels every running check. Call during shutdown.
+func (hc *HealthChecker) StopAll() {
+	hc.mu.Lock()
+	cancels := make([]context.CancelFunc, 0, len(hc.cancels))
+	for _, cancel := range hc.cancels {
+		cancels = append(cancels, cancel)
+	}
+	hc.cancels = make(map[string]context.CancelFunc)
+	hc.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// run probes node every spec.Interval (backing off on consecutive
+// failures) until ctx is cancelled or the node is evicted for failing
+// spec.FailuresBeforeCritical checks in a row.
+func (hc *HealthChecker) run(ctx context.Context, node *ServiceInfo, spec HealthCheckSpec) {
+	interval := spec.Interval
+	consecutiveFailures := 0
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := probe(ctx, node.Endpoint, spec); err != nil {
+			consecutiveFailures++
+			interval = backoffInterval(spec.Interval, consecutiveFailures)
+			Debugf("Health check failed for node '%s' of service '%s-%s' (%d/%d consecutive): %v",
+				node.NodeID, node.Name, node.Version, consecutiveFailures, spec.FailuresBeforeCritical, err)
+
+			if consecutiveFailures >= spec.FailuresBeforeCritical {
+				node.SetHealth(HealthCritical)
+				hc.evict(node)
+				return
+			}
+			node.SetHealth(HealthWarning)
+		} else {
+			consecutiveFailures = 0
+			interval = spec.Interval
+			node.SetHealth(HealthPassing)
+		}
+
+		timer.Reset(interval)
+	}
+}
+
+// backoffInterval doubles the base interval per consecutive failure beyond
+// the first, capped at 8x, so a flapping dependency isn't hammered while
+// it's down.
+func backoffInterval(base time.Duration, consecutiveFailures int) time.Duration {
+	multiplier := 1 << uint(consecutiveFailures-1)
+	if multiplier > 8 {
+		multiplier = 8
+	}
+	return base * time.Duration(multiplier)
+}
+
+// evict deregisters node and, if it was the last node for its service,
+// stops that service's proxy.
+func (hc *HealthChecker) evict(node *ServiceInfo) {
+	log.Printf("Node '%s' of service '%s-%s' failed too many health checks; deregistering.", node.NodeID, node.Name, node.Version)
+
+	if err := hc.store.Deregister(node.Name, node.Version, node.NodeID); err != nil {
+		log.Printf("Failed to deregister unhealthy node '%s': %v", node.NodeID, err)
+	}
+
+	hc.mu.Lock()
+	delete(hc.cancels, checkKey(node.Name, node.Version, node.NodeID))
+	hc.mu.Unlock()
+
+	if _, stillRegistered := hc.store.Get(node.Name, node.Version); stillRegistered {
+		return
+	}
+	if err := hc.proxyManager.StopProxy(node.Name, node.Version); err != nil {
+		log.Printf("Failed to stop proxy for service '%s-%s' after its last node failed health checks: %v", node.Name, node.Version, err)
+	}
+}
+
+// probe runs a single check of the given type against endpoint, bounded by
+// spec.Timeout.
+func probe(ctx context.Context, endpoint string, spec HealthCheckSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, spec.Timeout)
+	defer cancel()
+
+	switch spec.Type {
+	case CheckTCP:
+		return probeTCP(ctx, endpoint)
+	case CheckGRPC:
+		return probeGRPC(ctx, endpoint)
+	case CheckHTTP, "":
+		return probeHTTP(ctx, endpoint, spec.Path)
+	default:
+		return fmt.Errorf("unknown health check type %q", spec.Type)
+	}
+}
+
+func probeHTTP(ctx context.Context, endpoint, path string) error {
+	target := strings.TrimSuffix(endpoint, "/") + "/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, endpoint string) error {
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", target.Host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeGRPC(ctx context.Context, endpoint string) error {
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	conn, err := grpc.DialContext(ctx, target.Host, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return fmt.Errorf("grpc dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("grpc health check failed: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc health status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// core/logging.go
+// A minimal severity gate over the standard "log" package: Debugf is
+// suppressed unless SetLogLevel("debug") has been called, so per-probe
+// chatter (e.g. transient health-check failures) doesn't flood the log at
+// the sidecar's default level. There's deliberately nothing fancier here
+// (no structured fields, no per-package levels) until a component needs it.
+
+package core
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// debugEnabled is 1 once SetLogLevel has been called with "debug", 0
+// otherwise. Plain int32 behind atomic ops, since Debugf can be called
+// concurrently from every HealthChecker probe goroutine.
+var debugEnabled int32
+
+// SetLogLevel sets the threshold Debugf checks before logging. Only "debug"
+// is distinguished from everything else (configuration.Config.LogLevel's
+// "info"/"warn"/"error" values all just mean "debug logging is off"); call
+// it once at startup with cfg.LogLevel and again from a config-reload hook
+// to pick up a change without a restart.
+func SetLogLevel(level string) {
+	enabled := int32(0)
+	if level == "debug" {
+		enabled = 1
+	}
+	atomic.StoreInt32(&debugEnabled, enabled)
+}
+
+// Debugf logs format/args via the standard logger iff SetLogLevel("debug")
+// is currently in effect.
+func Debugf(format string, args ...interface{}) {
+	if atomic.LoadInt32(&debugEnabled) == 0 {
+		return
+	}
+	log.Printf(format, args...)
+}
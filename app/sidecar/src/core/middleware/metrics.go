@@ -0,0 +1,122 @@
+// core/middleware/metrics.go
+// Prometheus instrumentation: request count (by upstream status), latency
+// histogram, and an in-flight gauge, per service. Requires
+// github.com/prometheus/client_golang as a dependency.
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsConfig configures NewMetrics.
+type MetricsConfig struct {
+	// Registry is where the middleware's collectors are registered.
+	// Defaults to prometheus.DefaultRegisterer.
+	Registry prometheus.Registerer
+}
+
+// serviceMetrics holds the collectors one NewMetrics instance's requests
+// are recorded against: a curried view (service's labels pre-applied where
+// possible) over the collectors shared across every service registered
+// against the same Registerer.
+type serviceMetrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// sharedCollectors holds the CounterVec/HistogramVec/GaugeVec registered
+// once per Registerer. Metric names are fixed, so registering a second set
+// against the same registry (e.g. a second service with "metrics": true)
+// would panic on duplicate descriptors; every service instead shares these
+// and is distinguished by the "service" label.
+type sharedCollectors struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+var (
+	sharedMu         sync.Mutex
+	sharedByRegistry = map[prometheus.Registerer]*sharedCollectors{}
+)
+
+func collectorsFor(reg prometheus.Registerer) *sharedCollectors {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if sc, ok := sharedByRegistry[reg]; ok {
+		return sc
+	}
+
+	sc := &sharedCollectors{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "icaht_proxy_requests_total",
+			Help: "Total proxied requests, by service and upstream status code.",
+		}, []string{"service", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "icaht_proxy_request_duration_seconds",
+			Help:    "Proxied request latency in seconds, by service.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"service"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "icaht_proxy_in_flight_requests",
+			Help: "Proxied requests currently being handled.",
+		}, []string{"service"}),
+	}
+	reg.MustRegister(sc.requests, sc.latency, sc.inFlight)
+	sharedByRegistry[reg] = sc
+	return sc
+}
+
+func newServiceMetrics(reg prometheus.Registerer, service string) *serviceMetrics {
+	sc := collectorsFor(reg)
+	return &serviceMetrics{
+		requests: sc.requests,
+		latency:  sc.latency,
+		inFlight: sc.inFlight.WithLabelValues(service),
+	}
+}
+
+// statusRecorder captures the status code passed to WriteHeader, defaulting
+// to 200 if the wrapped handler never calls it explicitly, matching
+// net/http's own default.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// NewMetrics returns a middleware that records request count, latency, and
+// in-flight requests for service against cfg.Registry.
+func NewMetrics(service string, cfg MetricsConfig) Middleware {
+	registry := cfg.Registry
+	if registry == nil {
+		registry = prometheus.DefaultRegisterer
+	}
+	m := newServiceMetrics(registry, service)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.inFlight.Inc()
+			defer m.inFlight.Dec()
+
+			start := time.Now()
+			sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sr, r)
+
+			m.latency.WithLabelValues(service).Observe(time.Since(start).Seconds())
+			m.requests.WithLabelValues(service, strconv.Itoa(sr.status)).Inc()
+		})
+	}
+}
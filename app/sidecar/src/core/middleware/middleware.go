@@ -0,0 +1,24 @@
+// core/middleware/middleware.go
+// This package provides the pluggable middleware chain applied in front of
+// a proxied service's handler: auth, tracing, rate limiting, and metrics,
+// each in its own file. ProxyManager decides per service, from its
+// registration payload, which of these to install and in what order.
+
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with additional behavior, the same shape
+// used by net/http third-party routers; rest_client.Middleware is its
+// http.RoundTripper counterpart on the client side.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps final with mws in order, so the first entry in mws is the
+// outermost handler (it sees the request first and the response last).
+func Chain(final http.Handler, mws ...Middleware) http.Handler {
+	h := final
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
@@ -0,0 +1,123 @@
+// core/middleware/ratelimit.go
+// Token-bucket rate limiting keyed by client IP or authenticated subject.
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimitKeyFunc extracts the bucket key a request draws its token from.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyByClientIP keys by the request's remote IP, stripping the port.
+func KeyByClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// KeyByAuthSubject keys by the bearer token subject NewAuth stashed in the
+// request context, falling back to KeyByClientIP for requests that reached
+// this middleware without going through NewAuth first (or were anonymous).
+func KeyByAuthSubject(r *http.Request) string {
+	if subject, ok := SubjectFromContext(r.Context()); ok && subject != "" {
+		return subject
+	}
+	return KeyByClientIP(r)
+}
+
+// RateLimitConfig configures NewRateLimit.
+type RateLimitConfig struct {
+	// RequestsPerSecond is each bucket's steady-state refill rate.
+	RequestsPerSecond float64
+
+	// Burst is each bucket's capacity. Defaults to RequestsPerSecond rounded
+	// down to the nearest int, or 1 if that would be 0.
+	Burst int
+
+	// KeyFunc picks the bucket a request draws from. Defaults to KeyByClientIP.
+	KeyFunc RateLimitKeyFunc
+}
+
+func (cfg RateLimitConfig) withDefaults() RateLimitConfig {
+	if cfg.Burst <= 0 {
+		cfg.Burst = int(cfg.RequestsPerSecond)
+		if cfg.Burst <= 0 {
+			cfg.Burst = 1
+		}
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = KeyByClientIP
+	}
+	return cfg
+}
+
+// tokenBucket is a lazily-refilled token bucket: tokens accrue at rate per
+// second up to burst, and allow draws one if at least one is available.
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	rate     float64
+	burst    float64
+	lastFill time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{tokens: float64(burst), rate: rate, burst: float64(burst), lastFill: time.Now()}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastFill).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// NewRateLimit returns a middleware that answers 429 Too Many Requests once
+// a cfg.KeyFunc key exceeds cfg.RequestsPerSecond (bursting up to cfg.Burst).
+// Buckets are created lazily per key and kept for the life of the process;
+// this is intended for a bounded set of keys (client IPs or auth subjects
+// behind one sidecar), not for rate limiting an open-ended key space.
+func NewRateLimit(cfg RateLimitConfig) Middleware {
+	cfg = cfg.withDefaults()
+
+	var mu sync.Mutex
+	buckets := make(map[string]*tokenBucket)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := cfg.KeyFunc(r)
+
+			mu.Lock()
+			bucket, exists := buckets[key]
+			if !exists {
+				bucket = newTokenBucket(cfg.RequestsPerSecond, cfg.Burst)
+				buckets[key] = bucket
+			}
+			mu.Unlock()
+
+			if !bucket.allow() {
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// core/middleware/tracing.go
+// OpenTelemetry tracing: a middleware that starts/continues a span per
+// request, and a RoundTripper wrapper so the same trace context's
+// traceparent header reaches the upstream the request is proxied to.
+// Requires go.opentelemetry.io/otel and
+// go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp as
+// dependencies.
+
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewTracing returns a middleware that wraps the handler with an
+// OpenTelemetry span per request, named after spanName (typically the
+// service's name-version), extracting any inbound traceparent header to
+// continue a caller's trace instead of always starting a new one.
+func NewTracing(spanName string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, spanName)
+	}
+}
+
+// WrapTransport wraps rt so outgoing requests carry the traceparent header
+// of the span active in the request's context, continuing the trace across
+// the proxy hop to the upstream. A nil rt wraps http.DefaultTransport.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(rt)
+}
@@ -0,0 +1,93 @@
+// core/middleware_config.go
+// MiddlewareSpec is the wire shape for a service's middleware chain,
+// declared once in the registration payload that starts its shared proxy
+// (see StartProxy) and built here into the core/middleware chain installed
+// in front of the reverse proxy.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/intlcaht/icaht-sidecar/core/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AuthSpec enables bearer/JWT auth validated against a JWKS endpoint.
+type AuthSpec struct {
+	JWKSURL  string `json:"jwks_url"`
+	Issuer   string `json:"issuer,omitempty"`
+	Audience string `json:"audience,omitempty"`
+}
+
+// RateLimitSpec enables token-bucket rate limiting.
+type RateLimitSpec struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst,omitempty"`
+	// KeyBy selects what a request's bucket is keyed by: "ip" (default) or
+	// "subject" (the bearer token's subject; falls back to "ip" for
+	// requests Auth didn't authenticate, and if Auth isn't set at all).
+	KeyBy string `json:"key_by,omitempty"`
+}
+
+// MiddlewareSpec declares a service's middleware chain. Each stage is
+// skipped if its field is nil/false; the stages that are set always apply
+// in the fixed order auth, tracing, rate limiting, metrics, so a request
+// auth rejects never reaches tracing, rate limiting, metrics or the
+// upstream, and one the rate limiter rejects is traced but never reaches
+// metrics or the upstream.
+type MiddlewareSpec struct {
+	Auth      *AuthSpec      `json:"auth,omitempty"`
+	Tracing   bool           `json:"tracing,omitempty"`
+	RateLimit *RateLimitSpec `json:"rate_limit,omitempty"`
+	Metrics   bool           `json:"metrics,omitempty"`
+}
+
+// IsZero reports whether spec installs no middleware at all.
+func (spec MiddlewareSpec) IsZero() bool {
+	return spec.Auth == nil && !spec.Tracing && spec.RateLimit == nil && !spec.Metrics
+}
+
+// build turns spec into the ordered middleware chain StartProxy installs in
+// front of name-version's reverse proxy.
+func (spec MiddlewareSpec) build(name, version string) ([]middleware.Middleware, error) {
+	var chain []middleware.Middleware
+
+	if spec.Auth != nil {
+		if spec.Auth.JWKSURL == "" {
+			return nil, fmt.Errorf("middleware: auth requires a jwks_url")
+		}
+		chain = append(chain, middleware.NewAuth(middleware.AuthConfig{
+			JWKSURL:  spec.Auth.JWKSURL,
+			Issuer:   spec.Auth.Issuer,
+			Audience: spec.Auth.Audience,
+		}))
+	}
+
+	if spec.Tracing {
+		chain = append(chain, middleware.NewTracing(serviceKey(name, version)))
+	}
+
+	if spec.RateLimit != nil {
+		if spec.RateLimit.RequestsPerSecond <= 0 {
+			return nil, fmt.Errorf("middleware: rate_limit requires a positive requests_per_second")
+		}
+		keyFunc := middleware.KeyByClientIP
+		if spec.RateLimit.KeyBy == "subject" {
+			keyFunc = middleware.KeyByAuthSubject
+		}
+		chain = append(chain, middleware.NewRateLimit(middleware.RateLimitConfig{
+			RequestsPerSecond: spec.RateLimit.RequestsPerSecond,
+			Burst:             spec.RateLimit.Burst,
+			KeyFunc:           keyFunc,
+		}))
+	}
+
+	if spec.Metrics {
+		chain = append(chain, middleware.NewMetrics(serviceKey(name, version), middleware.MetricsConfig{
+			Registry: prometheus.DefaultRegisterer,
+		}))
+	}
+
+	return chain, nil
+}
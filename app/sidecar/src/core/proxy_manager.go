@@ -1,111 +1,657 @@
 // core/proxy_manager.go
 // This file manages the lifecycle of the reverse proxies for each
-// registered service. It starts a new proxy instance when a service
-// registers and stops it when a service deregisters.
+// registered service. It starts a single shared proxy per service the
+// first time any of its nodes registers, and load-balances requests to
+// that proxy across every healthy node using a pluggable strategy. Proxy
+// ports and load-balancing cursor state are local to this sidecar process,
+// so they live here rather than in RegistryStore, which may be backed by a
+// shared remote store.
+//
+// There's no separate "push config to the proxy" step for registrations:
+// Director calls selectNode, which reads the service's current nodes
+// (endpoint, weight, health) straight from the backing store on every
+// request. A re-register with a new endpoint, weight, or health change is
+// visible to the very next request with no explicit reload and no listener
+// restart. UpdateEndpoints is the explicit-push counterpart for callers that
+// aren't registering through the normal API (e.g. an xDS-style control
+// plane): it atomically swaps a service's node pool to a given []Upstream,
+// which selectNode prefers over the backing store's nodes whenever one has
+// been pushed, with the same no-restart guarantee.
 
 package core
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/intlcaht/icaht-sidecar/core/middleware"
 )
 
+// LoadBalanceStrategy selects which of a service's healthy nodes handles a
+// given proxied request.
+type LoadBalanceStrategy string
+
+const (
+	// RoundRobin cycles through healthy nodes in turn, ignoring weight.
+	RoundRobin LoadBalanceStrategy = "round_robin"
+	// Random picks a uniformly random healthy node, ignoring weight.
+	Random LoadBalanceStrategy = "random"
+	// LeastConnections picks the healthy node with the fewest in-flight
+	// proxied requests, ignoring weight.
+	LeastConnections LoadBalanceStrategy = "least_conn"
+	// Weighted picks a healthy node at random with probability proportional
+	// to its ServiceInfo.EffectiveWeight, so unevenly sized deployments can
+	// get an uneven share of traffic.
+	Weighted LoadBalanceStrategy = "weighted"
+)
+
+// ProxyManagerOption configures a ProxyManager constructed via NewProxyManager.
+type ProxyManagerOption func(*ProxyManager)
+
+// WithLoadBalanceStrategy sets the strategy used to pick a node for each
+// proxied request. Defaults to RoundRobin.
+func WithLoadBalanceStrategy(strategy LoadBalanceStrategy) ProxyManagerOption {
+	return func(pm *ProxyManager) {
+		pm.strategy = strategy
+	}
+}
+
+// WithTunnelManager enables reverse-tunnel routing: proxied requests to a
+// node registered via /register/tunnel are multiplexed over its tunnel
+// instead of dialed directly. Without this option, tunnel-registered nodes
+// are unreachable (their Endpoint is a synthetic tunnel:// URL).
+func WithTunnelManager(tunnels *TunnelManager) ProxyManagerOption {
+	return func(pm *ProxyManager) {
+		pm.tunnels = tunnels
+	}
+}
+
+// WithShutdownTimeout bounds how long StopProxy/ShutdownAll wait for a
+// proxy's in-flight requests to drain before its listener is torn down.
+// Defaults to 10s.
+func WithShutdownTimeout(timeout time.Duration) ProxyManagerOption {
+	return func(pm *ProxyManager) {
+		pm.shutdownTimeout = timeout
+	}
+}
+
+// WithEndpointHealthCheck enables StartEndpointHealthChecks: a background
+// loop that probes every upstream in every UpdateEndpoints-pushed endpoint
+// set on spec.Interval with the same probe HealthChecker uses, marking it
+// HealthPassing on success and HealthCritical on any single failure (there
+// is no HealthWarning step and no deregistration). Without this option,
+// upstreams pushed via UpdateEndpoints are never probed and stay whatever
+// health they started at (HealthPassing).
+func WithEndpointHealthCheck(spec HealthCheckSpec) ProxyManagerOption {
+	return func(pm *ProxyManager) {
+		pm.endpointCheckSpec = spec.withDefaults(defaultHealthCheckInterval)
+		pm.endpointChecksEnabled = true
+	}
+}
+
+// proxyEntry tracks the running server and round-robin cursor for one
+// service's shared proxy.
+type proxyEntry struct {
+	server   *http.Server
+	port     int
+	rrCursor uint64
+
+	name, version string    // Service this proxy was started for; used by Snapshot to look up its current nodes.
+	startedAt     time.Time // When the proxy was started; Snapshot reports uptime as time.Since this.
+	requests      uint64    // Proxied requests attempted so far, incremented by Director. Read/written only via atomic.
+}
+
 // ProxyManager manages the reverse proxies for registered services.
 type ProxyManager struct {
-	registry *Registry                // Reference to the service registry.
-	proxies  map[string]*httputil.ReverseProxy // Map of service key to its proxy.
-	mu       sync.RWMutex                    // Mutex to protect access to the proxies map.
-	portStart int                             // Starting port for proxy allocation.
+	store RegistryStore // Backing store consulted for each service's healthy nodes.
+
+	proxies  map[string]*proxyEntry // Map of service key to its running proxy.
+	starting map[string]struct{}    // Keys currently being built by StartProxy, reserved so a concurrent caller doesn't double-allocate a port for them.
+	mu       sync.RWMutex           // Mutex to protect access to the proxies map.
+
+	portInUse map[int]bool // Ports currently assigned to a running proxy.
+	portStart int          // Starting port for dynamic allocation.
+	nextPort  int          // The next available port to try.
+
+	strategy LoadBalanceStrategy // Load-balancing strategy applied across a service's nodes.
+	tunnels  *TunnelManager      // Active reverse tunnels; nil if tunnel registration is disabled.
+
+	shutdownTimeout time.Duration // Drain timeout given to each proxy's server.Shutdown call.
+
+	endpoints   map[string]*endpointSet // Service key to the xDS-style node set last pushed via UpdateEndpoints; overrides the backing store's nodes for that service while present.
+	endpointsMu sync.RWMutex            // Guards endpoints; read by selectNode on every request, written by UpdateEndpoints.
+
+	endpointCheckSpec     HealthCheckSpec // Probe config for the background endpoint health-check loop; meaningless unless endpointChecksEnabled.
+	endpointChecksEnabled bool            // Set by WithEndpointHealthCheck; StartEndpointHealthChecks is a no-op otherwise.
+	stopEndpointChecks    chan struct{}   // Closed by StartEndpointHealthChecks's stop func to end its goroutine.
 }
 
-// NewProxyManager creates and initializes a new ProxyManager.
-func NewProxyManager(registry *Registry, portStart int) *ProxyManager {
+// Upstream configures one upstream URL in a service's xDS-style endpoint
+// set: the data-plane config UpdateEndpoints pushes directly to a
+// ProxyManager, bypassing service registration entirely. Push a new
+// []Upstream for a service at any time and selectNode picks it up on the
+// very next request, with no listener restart and no dropped in-flight
+// connections.
+type Upstream struct {
+	Endpoint string // Address to proxy to, e.g. "http://10.0.1.4:8080".
+	Weight   int    // Relative share of traffic under the Weighted strategy; zero or negative means 1, same as ServiceInfo.EffectiveWeight.
+}
+
+// endpointSet holds one service's xDS-style node pool: the synthetic
+// ServiceInfo nodes UpdateEndpoints materializes from the last []Upstream
+// pushed for it. Representing them as ServiceInfo, the same type registry
+// nodes use, lets selectNode, the health-state vocabulary, and the
+// least-connections counter work unmodified across both sources.
+type endpointSet struct {
+	nodes []*ServiceInfo
+}
+
+// defaultShutdownTimeout is how long a proxy's server.Shutdown call waits
+// for in-flight requests to drain when WithShutdownTimeout isn't supplied.
+const defaultShutdownTimeout = 10 * time.Second
+
+// NewProxyManager creates and initializes a new ProxyManager backed by store.
+func NewProxyManager(store RegistryStore, portStart int, opts ...ProxyManagerOption) *ProxyManager {
 	pm := &ProxyManager{
-		registry:  registry,
-		proxies:   make(map[string]*httputil.ReverseProxy),
-		portStart: portStart,
+		store:           store,
+		proxies:         make(map[string]*proxyEntry),
+		starting:        make(map[string]struct{}),
+		portInUse:       make(map[int]bool),
+		portStart:       portStart,
+		nextPort:        portStart,
+		strategy:        RoundRobin,
+		shutdownTimeout: defaultShutdownTimeout,
+		endpoints:       make(map[string]*endpointSet),
+	}
+	for _, opt := range opts {
+		opt(pm)
 	}
-	pm.registry.SetPortRange(portStart)
 	return pm
 }
 
-// StartProxy starts a new reverse proxy for the given service information.
-func (pm *ProxyManager) StartProxy(serviceInfo *ServiceInfo) error {
-	targetURL, err := url.Parse(serviceInfo.Endpoint)
+// SetPortRangeStart changes where future port allocations search from. It
+// only affects proxies started after the call; ports already handed out to
+// running proxies are left alone, so this is safe to call from a config
+// hot-reload path without disturbing active traffic.
+func (pm *ProxyManager) SetPortRangeStart(port int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.portStart = port
+	pm.nextPort = port
+}
+
+// SetShutdownTimeout changes the drain timeout used by StopProxy/ShutdownAll
+// calls made after this call returns; a shutdown already in progress keeps
+// the timeout it started with.
+func (pm *ProxyManager) SetShutdownTimeout(timeout time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.shutdownTimeout = timeout
+}
+
+// selectedNodeKey is the context key under which the node chosen by Director
+// is stashed, so ModifyResponse/ErrorHandler can release its connection count.
+type selectedNodeKey struct{}
+
+// StartProxy ensures a reverse proxy is running for serviceInfo's service
+// and returns the port it listens on. The first node to register a given
+// name-version starts the shared proxy, allocates its port, and installs
+// mwSpec's middleware chain in front of it; later nodes simply join the
+// load-balancing pool via the registry, so this is a no-op (returning the
+// already-allocated port, mwSpec ignored) if the proxy is already running.
+// A key stays reserved in pm.starting (port held, entry withheld from
+// pm.proxies) until the middleware chain and server are fully built, so a
+// failure here never leaves a server==nil entry that later calls would
+// mistake for a running proxy.
+func (pm *ProxyManager) StartProxy(serviceInfo *ServiceInfo, mwSpec MiddlewareSpec) (int, error) {
+	key := serviceKey(serviceInfo.Name, serviceInfo.Version)
+
+	pm.mu.Lock()
+	if entry, exists := pm.proxies[key]; exists {
+		pm.mu.Unlock()
+		return entry.port, nil
+	}
+	if _, building := pm.starting[key]; building {
+		pm.mu.Unlock()
+		return 0, fmt.Errorf("proxy for service '%s' is already starting", key)
+	}
+
+	port, err := pm.allocatePortLocked()
 	if err != nil {
-		return fmt.Errorf("failed to parse service endpoint '%s': %v", serviceInfo.Endpoint, err)
+		pm.mu.Unlock()
+		return 0, fmt.Errorf("failed to allocate port for service '%s': %w", key, err)
+	}
+	pm.starting[key] = struct{}{}
+	pm.mu.Unlock()
+
+	entry := &proxyEntry{port: port, name: serviceInfo.Name, version: serviceInfo.Version, startedAt: time.Now()}
+
+	chain, err := mwSpec.build(serviceInfo.Name, serviceInfo.Version)
+	if err != nil {
+		pm.mu.Lock()
+		delete(pm.starting, key)
+		delete(pm.portInUse, port)
+		pm.mu.Unlock()
+		return 0, fmt.Errorf("failed to build middleware chain for service '%s': %w", key, err)
+	}
+
+	name, version := serviceInfo.Name, serviceInfo.Version
+	director := func(req *http.Request) {
+		atomic.AddUint64(&entry.requests, 1)
+
+		node, err := pm.selectNode(entry, name, version)
+		if err != nil {
+			log.Printf("Proxy for '%s-%s' could not select a node: %v", name, version, err)
+			return
+		}
+
+		target, err := url.Parse(node.Endpoint)
+		if err != nil {
+			log.Printf("Proxy for '%s-%s' has an invalid node endpoint '%s': %v", name, version, node.Endpoint, err)
+			return
+		}
+
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		req.Host = target.Host
+
+		node.AddActiveConn(1)
+		*req = *req.WithContext(context.WithValue(req.Context(), selectedNodeKey{}, node))
+	}
+
+	releaseConn := func(req *http.Request) {
+		if node, ok := req.Context().Value(selectedNodeKey{}).(*ServiceInfo); ok {
+			node.AddActiveConn(-1)
+		}
 	}
 
-	reverseProxy := httputil.NewSingleHostReverseProxy(targetURL)
+	transport := pm.transport()
+	if mwSpec.Tracing {
+		transport = middleware.WrapTransport(transport)
+	}
 
-	// Apply any middleware to the reverse proxy handler here.
-	handler := http.Handler(reverseProxy)
-	// Example: handler = auth.Middleware(handler)
-	// Example: handler = tracing.Middleware(handler)
-	// ...
+	reverseProxy := &httputil.ReverseProxy{
+		Director:  director,
+		Transport: transport,
+		ModifyResponse: func(resp *http.Response) error {
+			releaseConn(resp.Request)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			releaseConn(req)
+			log.Printf("Proxy for '%s-%s' failed to reach upstream: %v", name, version, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
 
-	server := &http.Server{
-		Addr:    ":" + strconv.Itoa(serviceInfo.ProxyPort),
-		Handler: handler,
+	entry.server = &http.Server{
+		Addr:    ":" + strconv.Itoa(port),
+		Handler: middleware.Chain(reverseProxy, chain...),
 	}
 
-	serviceKey := fmt.Sprintf("%s-%s", serviceInfo.Name, serviceInfo.Version)
 	pm.mu.Lock()
-	pm.proxies[serviceKey] = reverseProxy // Store the reverse proxy instance (might be useful later)
+	delete(pm.starting, key)
+	pm.proxies[key] = entry
 	pm.mu.Unlock()
 
-	log.Printf("Starting proxy for service '%s' (%s) on port %d, forwarding to '%s'",
-		serviceInfo.Name, serviceInfo.Version, serviceInfo.ProxyPort, serviceInfo.Endpoint)
+	log.Printf("Starting proxy for service '%s-%s' on port %d, load-balancing via %q", name, version, port, pm.strategy)
 
-	// Start the proxy server in a goroutine.
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start proxy for '%s': %v", serviceKey, err)
-			// Consider implementing a retry or cleanup mechanism here.
+		if err := entry.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Proxy for '%s-%s' stopped unexpectedly: %v", name, version, err)
 		}
-		log.Printf("Proxy for service '%s' stopped.", serviceKey)
 	}()
 
+	return port, nil
+}
+
+// transport returns the RoundTripper a service's reverse proxy should use:
+// nil (ReverseProxy's default, http.DefaultTransport) if no TunnelManager is
+// configured, or one that additionally routes tunnel-registered nodes over
+// their multiplexed connection instead of dialing them directly.
+func (pm *ProxyManager) transport() http.RoundTripper {
+	if pm.tunnels == nil {
+		return nil
+	}
+	return &tunnelTransport{tunnels: pm.tunnels, direct: http.DefaultTransport}
+}
+
+// selectNode fetches the service's current nodes and picks a healthy one
+// according to pm.strategy.
+func (pm *ProxyManager) selectNode(entry *proxyEntry, name, version string) (*ServiceInfo, error) {
+	nodes, err := pm.nodesFor(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var healthy []*ServiceInfo
+	for _, node := range nodes {
+		if node.Health() != HealthCritical {
+			healthy = append(healthy, node)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("no healthy nodes for service '%s-%s'", name, version)
+	}
+
+	switch pm.strategy {
+	case Random:
+		return healthy[rand.Intn(len(healthy))], nil
+	case LeastConnections:
+		best := healthy[0]
+		for _, node := range healthy[1:] {
+			if node.ActiveConns() < best.ActiveConns() {
+				best = node
+			}
+		}
+		return best, nil
+	case RoundRobin, "":
+		offset := atomic.AddUint64(&entry.rrCursor, 1) - 1
+		return healthy[offset%uint64(len(healthy))], nil
+	case Weighted:
+		return weightedPick(healthy), nil
+	default:
+		return nil, fmt.Errorf("unknown load balance strategy %q", pm.strategy)
+	}
+}
+
+// weightedPick picks a random node from healthy with probability
+// proportional to its EffectiveWeight.
+func weightedPick(healthy []*ServiceInfo) *ServiceInfo {
+	total := 0
+	for _, node := range healthy {
+		total += node.EffectiveWeight()
+	}
+
+	target := rand.Intn(total)
+	for _, node := range healthy {
+		target -= node.EffectiveWeight()
+		if target < 0 {
+			return node
+		}
+	}
+	return healthy[len(healthy)-1] // Unreachable: target < total, so the loop always returns; kept so the function compiles.
+}
+
+// nodesFor returns the node pool selectNode load-balances across for
+// name-version: the xDS-style set last pushed via UpdateEndpoints if one
+// exists, otherwise whatever's registered in the backing store. Read under
+// endpointsMu's RLock so a concurrent UpdateEndpoints push is picked up by
+// the very next request.
+func (pm *ProxyManager) nodesFor(name, version string) ([]*ServiceInfo, error) {
+	key := serviceKey(name, version)
+
+	pm.endpointsMu.RLock()
+	set, overridden := pm.endpoints[key]
+	pm.endpointsMu.RUnlock()
+	if overridden {
+		return set.nodes, nil
+	}
+
+	nodes, ok := pm.store.Get(name, version)
+	if !ok {
+		return nil, fmt.Errorf("service with name '%s' and version '%s' not found", name, version)
+	}
+	return nodes, nil
+}
+
+// UpdateEndpoints atomically swaps the endpoint set selectNode
+// load-balances across for name-version to upstreams: the xDS-style
+// counterpart to registering nodes one at a time. The swap takes effect on
+// the very next request, with no listener restart and no dropped in-flight
+// connections, same as a normal re-registration — this is what lets
+// registrations (or an external control plane) push config instead of
+// churning listeners. Passing an empty upstreams clears the override, so
+// selectNode falls back to whatever's registered in the backing store.
+func (pm *ProxyManager) UpdateEndpoints(name, version string, upstreams []Upstream) {
+	key := serviceKey(name, version)
+
+	nodes := make([]*ServiceInfo, len(upstreams))
+	for i, up := range upstreams {
+		nodes[i] = &ServiceInfo{
+			Name:     name,
+			Version:  version,
+			NodeID:   up.Endpoint,
+			Endpoint: up.Endpoint,
+			Weight:   up.Weight,
+		}
+	}
+
+	pm.endpointsMu.Lock()
+	if len(nodes) == 0 {
+		delete(pm.endpoints, key)
+	} else {
+		pm.endpoints[key] = &endpointSet{nodes: nodes}
+	}
+	pm.endpointsMu.Unlock()
+
+	log.Printf("Updated endpoint set for service '%s-%s' to %d upstream(s)", name, version, len(nodes))
+}
+
+// StartEndpointHealthChecks begins periodically probing every upstream in
+// every UpdateEndpoints-pushed endpoint set, per the spec given to
+// WithEndpointHealthCheck, marking each one HealthPassing or HealthCritical
+// with the same probe logic HealthChecker uses for registered nodes. A
+// no-op, returning a no-op stop func, if WithEndpointHealthCheck wasn't set.
+// The returned stop function ends the loop; call it at most once.
+func (pm *ProxyManager) StartEndpointHealthChecks() (stop func()) {
+	if !pm.endpointChecksEnabled {
+		return func() {}
+	}
+
+	pm.stopEndpointChecks = make(chan struct{})
+	ticker := time.NewTicker(pm.endpointCheckSpec.Interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pm.stopEndpointChecks:
+				return
+			case <-ticker.C:
+				pm.probeEndpoints()
+			}
+		}
+	}()
+
+	return func() {
+		close(pm.stopEndpointChecks)
+	}
+}
+
+// probeEndpoints runs one round of health checks, concurrently, across
+// every node in every UpdateEndpoints-pushed endpoint set.
+func (pm *ProxyManager) probeEndpoints() {
+	pm.endpointsMu.RLock()
+	var nodes []*ServiceInfo
+	for _, set := range pm.endpoints {
+		nodes = append(nodes, set.nodes...)
+	}
+	pm.endpointsMu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *ServiceInfo) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), pm.endpointCheckSpec.Timeout)
+			defer cancel()
+
+			if err := probe(ctx, node.Endpoint, pm.endpointCheckSpec); err != nil {
+				node.SetHealth(HealthCritical)
+				log.Printf("Endpoint health check failed for '%s' (service '%s-%s'): %v", node.Endpoint, node.Name, node.Version, err)
+				return
+			}
+			node.SetHealth(HealthPassing)
+		}(node)
+	}
+	wg.Wait()
+}
+
+// RestoreProxies starts a shared proxy for every still-valid service found
+// in the backing store, which for a persistent store like BoltRegistry
+// means whatever survived a sidecar restart. Only one proxy is started per
+// service; every node already joins its load-balancing pool the same way a
+// fresh registration would, once the service's nodes are Get again. Call
+// this once at startup, before the registration API starts accepting new
+// registrations, so existing clients aren't briefly left unreachable.
+//
+// Middleware selection is local to this sidecar process, not persisted with
+// the node (see MiddlewareSpec), so a restored proxy comes back with no
+// middleware installed. A later registration joins the already-running
+// proxy and its middleware is ignored (see StartProxy); a chain is only
+// installed once the proxy has been stopped and is started again.
+func (pm *ProxyManager) RestoreProxies() error {
+	var errs []error
+	for key, nodes := range pm.store.List() {
+		representative := firstNonExpired(nodes)
+		if representative == nil {
+			continue // Every node under this service has already lapsed; let it be.
+		}
+		if _, err := pm.StartProxy(representative, MiddlewareSpec{}); err != nil {
+			errs = append(errs, fmt.Errorf("service '%s': %w", key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("errors restoring proxies: %v", errs)
+	}
 	return nil
 }
 
-// StopProxy stops the reverse proxy for the given service.
+// firstNonExpired returns the first node in nodes whose lease hasn't
+// elapsed, or nil if every one has.
+func firstNonExpired(nodes []*ServiceInfo) *ServiceInfo {
+	now := time.Now()
+	for _, node := range nodes {
+		if node.ExpiresAt.IsZero() || node.ExpiresAt.After(now) {
+			return node
+		}
+	}
+	return nil
+}
+
+// StopProxy gracefully shuts down the shared proxy for the given service and
+// releases its port. Call this only once a service has no nodes left
+// registered.
 func (pm *ProxyManager) StopProxy(name, version string) error {
-	serviceKey := fmt.Sprintf("%s-%s", name, version)
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	key := serviceKey(name, version)
 
-	proxy := pm.proxies[serviceKey]
-	if proxy == nil {
-		return fmt.Errorf("no proxy found for service '%s'", serviceKey)
+	pm.mu.Lock()
+	entry, exists := pm.proxies[key]
+	if !exists {
+		pm.mu.Unlock()
+		return fmt.Errorf("no proxy found for service '%s'", key)
 	}
+	delete(pm.proxies, key)
+	delete(pm.portInUse, entry.port)
+	pm.mu.Unlock()
 
-	// Graceful shutdown of the server associated with this proxy would be more robust.
-	// This example doesn't keep a direct reference to the http.Server.
-	// A more advanced implementation might store the *http.Server in the ProxyManager
-	// and call Shutdown() on it.
-	delete(pm.proxies, serviceKey)
-	log.Printf("Stopped proxy for service '%s'", serviceKey)
+	ctx, cancel := context.WithTimeout(context.Background(), pm.shutdownTimeout)
+	defer cancel()
+	if err := entry.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down proxy for '%s': %w", key, err)
+	}
+	log.Printf("Stopped proxy for service '%s'", key)
 	return nil
 }
 
-// ShutdownAll stops all running proxies.
-func (pm *ProxyManager) ShutdownAll() error {
+// ShutdownAll gracefully stops every running proxy, one after another,
+// giving each one up to pm.shutdownTimeout to drain in-flight requests, or
+// less if ctx (e.g. the process's own shutdown deadline from a
+// SIGINT/SIGTERM handler) is done first.
+func (pm *ProxyManager) ShutdownAll(ctx context.Context) error {
 	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	entries := make(map[string]*proxyEntry, len(pm.proxies))
+	for key, entry := range pm.proxies {
+		entries[key] = entry
+	}
+	pm.proxies = make(map[string]*proxyEntry)
+	pm.portInUse = make(map[int]bool)
+	pm.mu.Unlock()
 
 	var errorList []error
-	for key := range pm.proxies {
-		// As mentioned in StopProxy, a more robust shutdown would involve
-		// managing the http.Server instances. For this basic example,
-		// we just log the stopping action.
+	for key, entry := range entries {
 		log.Printf("Stopping proxy for service '%s'...", key)
-		delete(pm.proxies, key)
+		shutdownCtx, cancel := context.WithTimeout(ctx, pm.shutdownTimeout)
+		err := entry.server.Shutdown(shutdownCtx)
+		cancel()
+		if err != nil {
+			errorList = append(errorList, fmt.Errorf("service '%s': %w", key, err))
+		}
+	}
+	if len(errorList) > 0 {
+		return fmt.Errorf("errors shutting down proxies: %v", errorList)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// ProxyStatus is a point-in-time status summary of one running proxy, as
+// returned by Snapshot.
+type ProxyStatus struct {
+	Key      string        `json:"key"`
+	Upstream string        `json:"upstream"`
+	Port     int           `json:"port"`
+	Uptime   time.Duration `json:"uptime"`
+	Requests uint64        `json:"requests"`
+}
+
+// Snapshot returns a status summary of every running proxy, for the
+// /v1/proxies diagnostic endpoint. Upstream is the endpoint selectNode
+// would currently favor, not necessarily the one the most recent request
+// was routed to.
+func (pm *ProxyManager) Snapshot() []ProxyStatus {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	statuses := make([]ProxyStatus, 0, len(pm.proxies))
+	for key, entry := range pm.proxies {
+		var upstream string
+		if node, err := pm.selectNode(entry, entry.name, entry.version); err == nil {
+			upstream = node.Endpoint
+		}
+		statuses = append(statuses, ProxyStatus{
+			Key:      key,
+			Upstream: upstream,
+			Port:     entry.port,
+			Uptime:   time.Since(entry.startedAt),
+			Requests: atomic.LoadUint64(&entry.requests),
+		})
+	}
+	return statuses
+}
+
+// allocatePortLocked finds and reserves a unique port for a service proxy.
+// It records the port it started searching from before making any move, so
+// wrap-around is detected by returning to that exact sentinel rather than
+// by comparing against pm.nextPort, which has already moved past it by the
+// time a collision is found. Callers must hold pm.mu.
+func (pm *ProxyManager) allocatePortLocked() (int, error) {
+	if pm.portStart == 0 {
+		return 0, fmt.Errorf("port range not initialized")
+	}
+
+	start := pm.nextPort
+	for {
+		port := pm.nextPort
+		pm.nextPort++
+		if pm.nextPort > 65535 {
+			pm.nextPort = pm.portStart // Wrap around once the range is exhausted.
+		}
+		if !pm.portInUse[port] {
+			pm.portInUse[port] = true
+			return port, nil
+		}
+		if pm.nextPort == start { // We've circled back to where we began; every port is taken.
+			return 0, fmt.Errorf("no available ports in the configured range")
+		}
+	}
+}
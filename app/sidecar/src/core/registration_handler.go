@@ -10,33 +10,82 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 )
 
 // RegistrationRequest represents the expected JSON payload for service registration.
 type RegistrationRequest struct {
-	Name     string            `json:"name"`
-	Version  string            `json:"version"`
-	Endpoint string            `json:"endpoint"`
-	Metadata map[string]string `json:"metadata"`
+	Name        string              `json:"name"`
+	Version     string              `json:"version"`
+	Endpoint    string              `json:"endpoint"`
+	Metadata    map[string]string   `json:"metadata"`
+	NodeID      string              `json:"node_id,omitempty"`      // Identifies this instance; omit to derive one from the endpoint
+	TTLSeconds  int                 `json:"ttl_seconds,omitempty"`  // Lease TTL; omit or 0 means no expiry
+	Weight      int                 `json:"weight,omitempty"`       // Relative share of traffic under the Weighted strategy; omit or 0 means 1
+	HealthCheck *HealthCheckRequest `json:"health_check,omitempty"` // Omit to register without active health checking
+	Middleware  MiddlewareSpec      `json:"middleware,omitempty"`   // Chain installed in front of this service's shared proxy; only honored by the first node to register it
+}
+
+// HealthCheckRequest declares how the sidecar should actively probe a
+// node's Endpoint after it registers.
+type HealthCheckRequest struct {
+	Type                   string `json:"type"`                               // "http" (default), "tcp", or "grpc"
+	Path                   string `json:"path,omitempty"`                     // HTTP check path; defaults to "/"
+	IntervalSeconds        int    `json:"interval_seconds,omitempty"`         // Defaults to the sidecar's default_health_check_interval (10s unless configured)
+	TimeoutSeconds         int    `json:"timeout_seconds,omitempty"`          // Defaults to IntervalSeconds/2
+	FailuresBeforeCritical int    `json:"failures_before_critical,omitempty"` // Defaults to 3
+}
+
+// healthCheckSpecFromRequest converts the wire HealthCheckRequest into the
+// HealthCheckSpec HealthChecker operates on, applying the same
+// seconds-to-Duration conversion used for TTLSeconds above.
+func healthCheckSpecFromRequest(req HealthCheckRequest) HealthCheckSpec {
+	spec := HealthCheckSpec{
+		Type:                   CheckType(req.Type),
+		Path:                   req.Path,
+		FailuresBeforeCritical: req.FailuresBeforeCritical,
+	}
+	if req.IntervalSeconds > 0 {
+		spec.Interval = time.Duration(req.IntervalSeconds) * time.Second
+	}
+	if req.TimeoutSeconds > 0 {
+		spec.Timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	return spec
+}
+
+// HeartbeatRequest represents the expected JSON payload for POST /heartbeat.
+type HeartbeatRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	NodeID  string `json:"node_id,omitempty"`
 }
 
 // RegistrationResponse represents the JSON payload returned after registration.
 type RegistrationResponse struct {
 	Message   string `json:"message"`
 	ProxyPort int    `json:"proxy_port"`
+	NodeID    string `json:"node_id"`
 }
 
-// RegistrationHandler handles incoming service registration requests.
+// RegistrationHandler handles incoming service registration requests. It
+// depends only on the RegistryStore interface, so the backing store
+// (in-memory, etcd, Consul, Redis) is an operator choice made when main
+// constructs the registry.
 type RegistrationHandler struct {
-	registry     *Registry
-	proxyManager *ProxyManager
+	registry      RegistryStore
+	proxyManager  *ProxyManager
+	healthChecker *HealthChecker
 }
 
-// NewRegistrationHandler creates a new RegistrationHandler.
-func NewRegistrationHandler(registry *Registry, proxyManager *ProxyManager) *RegistrationHandler {
+// NewRegistrationHandler creates a new RegistrationHandler. healthChecker
+// may be nil, in which case registrations that request a health_check are
+// accepted but no active probing is started.
+func NewRegistrationHandler(registry RegistryStore, proxyManager *ProxyManager, healthChecker *HealthChecker) *RegistrationHandler {
 	return &RegistrationHandler{
-		registry:     registry,
-		proxyManager: proxyManager,
+		registry:      registry,
+		proxyManager:  proxyManager,
+		healthChecker: healthChecker,
 	}
 }
 
@@ -61,23 +110,37 @@ func (h *RegistrationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("Received registration request for service '%s' (%s) with endpoint '%s'", req.Name, req.Version, req.Endpoint)
 
-	serviceInfo, err := h.registry.RegisterService(req.Name, req.Version, req.Endpoint, req.Metadata)
+	opts := RegisterOptions{Weight: req.Weight}
+	if req.TTLSeconds > 0 {
+		opts.TTL = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	serviceInfo, err := h.registry.Register(req.Name, req.Version, req.Endpoint, req.Metadata, req.NodeID, opts)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to register service: %v",err), http.StatusInternalServerError)
 		return
 	}
 
-	// Once the service is registered, start its proxy.
-	if err := h.proxyManager.StartProxy(serviceInfo); err != nil {
-		// If proxy start fails, consider de registering the service to avoid inconsistencies.
-		h.registry.DeregisterService(req.Name, req.Version)
+	// Once the service's first node is registered, start its shared proxy
+	// with req.Middleware's chain installed. Later nodes join the same
+	// proxy's load-balancing pool, so this just returns the already-allocated
+	// port for them and req.Middleware is ignored.
+	proxyPort, err := h.proxyManager.StartProxy(serviceInfo, req.Middleware)
+	if err != nil {
+		// If proxy start fails, consider de registering the node to avoid inconsistencies.
+		h.registry.Deregister(req.Name, req.Version, serviceInfo.NodeID)
 		http.Error(w, fmt.Sprintf("Failed to start proxy for service '%s': %v", req.Name, err), http.StatusInternalServerError)
 		return
 	}
 
+	if req.HealthCheck != nil && h.healthChecker != nil {
+		h.healthChecker.Start(serviceInfo, healthCheckSpecFromRequest(*req.HealthCheck))
+	}
+
 	resp := RegistrationResponse{
-		Message:   fmt.Sprintf("Service '%s' (%s) registered successfully.", req.Name, req.Version),
-		ProxyPort: serviceInfo.ProxyPort,
+		Message:   fmt.Sprintf("Service '%s' (%s) node '%s' registered successfully.", req.Name, req.Version, serviceInfo.NodeID),
+		ProxyPort: proxyPort,
+		NodeID:    serviceInfo.NodeID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -86,5 +149,44 @@ func (h *RegistrationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		// We've already sent a 200 OK status, so just log the error.
 	}
 
-	log.Printf("Service '%s' (%s) registered and proxy started on port %d.", req.Name, req.Version, serviceInfo.ProxyPort)
+	log.Printf("Service '%s' (%s) node '%s' registered; proxy listening on port %d.", req.Name, req.Version, serviceInfo.NodeID, proxyPort)
+}
+
+// HeartbeatHandler handles lease-renewal requests from registered services.
+// A service that registered with a TTL must call this endpoint at its
+// RegisterInterval or be deregistered when the lease expires.
+type HeartbeatHandler struct {
+	registry RegistryStore
+}
+
+// NewHeartbeatHandler creates a new HeartbeatHandler.
+func NewHeartbeatHandler(registry RegistryStore) *HeartbeatHandler {
+	return &HeartbeatHandler{registry: registry}
+}
+
+// ServeHTTP implements the http.Handler interface for the heartbeat endpoint.
+func (h *HeartbeatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req HeartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name == "" || req.Version == "" || req.NodeID == "" {
+		http.Error(w, "Missing required fields (name, version, node_id)", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.registry.Heartbeat(req.Name, req.Version, req.NodeID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to record heartbeat: %v", err), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
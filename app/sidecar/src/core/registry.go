@@ -1,134 +1,437 @@
 // core/registry.go
-// This file defines the service registry, which is responsible for
-// storing and managing information about the registered microservices.
-// It provides mechanisms to add, retrieve, and remove service details.
+// This file defines MemoryRegistry, the default in-process implementation
+// of RegistryStore. It stores every service's nodes in a map guarded by a
+// mutex and fans out Create/Update/Delete events to any active Watch calls.
 
 package core
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// ServiceInfo holds the metadata of a registered microservice.
+// watchBufferSize bounds how many events a slow watcher can fall behind by
+// before further events are dropped for it.
+const watchBufferSize = 16
+
+// HealthState reports the outcome of HealthChecker's most recent probes of
+// a node, mirroring Consul's passing/warning/critical vocabulary.
+type HealthState string
+
+const (
+	// HealthPassing means recent checks succeeded, or none have run yet.
+	HealthPassing HealthState = "passing"
+	// HealthWarning means the node has failed at least one check but not
+	// enough consecutive ones to be taken out of rotation.
+	HealthWarning HealthState = "warning"
+	// HealthCritical means the node failed enough consecutive checks to be
+	// excluded from load balancing and deregistered.
+	HealthCritical HealthState = "critical"
+)
+
+// ServiceInfo holds the metadata of a single registered service instance
+// (node). Multiple nodes can share the same Name and Version; they are
+// distinguished by NodeID and load-balanced across by the proxy manager.
 type ServiceInfo struct {
-	Name     string            // Name of the service.
-	Version  string            // Version of the service.
-	Endpoint string            // The actual address of the microservice.
-	Metadata map[string]string // Additional metadata provided during registration.
-	ProxyPort int               // The port assigned to the proxy for this service.
+	Name      string            // Name of the service.
+	Version   string            // Version of the service.
+	NodeID    string            // Identifies this instance within the service; unique per Name+Version.
+	Endpoint  string            // The actual address of this instance.
+	Metadata  map[string]string // Additional metadata provided during registration.
+	LeaseTTL  time.Duration     // TTL the node registered with; zero means no expiry.
+	ExpiresAt time.Time         // When the current lease expires; zero means no expiry.
+	Weight    int               // Relative share of traffic under the Weighted strategy; see EffectiveWeight.
+
+	activeConns int64        // In-flight proxied requests, tracked for the least-connections strategy.
+	health      atomic.Value // Holds a HealthState; mutated by HealthChecker, read by ProxyManager.
+}
+
+// EffectiveWeight reports node's weight for the Weighted load-balancing
+// strategy, treating an unset or non-positive Weight as 1 so nodes that
+// registered without one still get an equal share instead of none.
+func (node *ServiceInfo) EffectiveWeight() int {
+	if node.Weight <= 0 {
+		return 1
+	}
+	return node.Weight
+}
+
+// AddActiveConn adjusts a node's in-flight request count, used by the proxy
+// manager to track connections for the least-connections strategy. delta is
+// typically +1 when a request starts and -1 when it finishes.
+func (node *ServiceInfo) AddActiveConn(delta int64) {
+	atomic.AddInt64(&node.activeConns, delta)
 }
 
-// Registry is a thread-safe store for registered services.
-type Registry struct {
-	services map[string]*ServiceInfo // Map of service name to its information.
-	mu       sync.RWMutex          // Mutex to protect access to the services map.
-	portInUse map[int]bool          // Keep track of ports that are currently in use.
-	portStart int                   // Starting port for dynamic allocation.
-	nextPort  int                   // The next available port to assign.
+// ActiveConns reports a node's current in-flight request count.
+func (node *ServiceInfo) ActiveConns() int64 {
+	return atomic.LoadInt64(&node.activeConns)
 }
 
-// NewRegistry creates and initializes a new service registry.
-func NewRegistry() *Registry {
-	return &Registry{
-		services:  make(map[string]*ServiceInfo),
-		portInUse: make(map[int]bool),
+// Health reports the node's current health state. A node that has never had
+// its health set (e.g. no HealthChecker is watching it) reads as
+// HealthPassing, so it remains eligible for load balancing by default.
+func (node *ServiceInfo) Health() HealthState {
+	if state, ok := node.health.Load().(HealthState); ok {
+		return state
 	}
+	return HealthPassing
 }
 
-// SetPortRange sets the starting port for dynamic port allocation.
-func (r *Registry) SetPortRange(startPort int) {
-	r.portStart = startPort
-	r.nextPort = startPort
+// SetHealth records the outcome of the most recent health check.
+func (node *ServiceInfo) SetHealth(state HealthState) {
+	node.health.Store(state)
 }
 
-// RegisterService adds a new service to the registry and assigns it a proxy port.
-func (r *Registry) RegisterService(name, version, endpoint string, metadata map[string]string) (*ServiceInfo, error) {
+// serviceInfoWire is the JSON wire representation of ServiceInfo. It exists
+// because ServiceInfo.health is an atomic.Value, which encoding/json cannot
+// marshal directly.
+type serviceInfoWire struct {
+	Name      string            `json:"Name"`
+	Version   string            `json:"Version"`
+	NodeID    string            `json:"NodeID"`
+	Endpoint  string            `json:"Endpoint"`
+	Metadata  map[string]string `json:"Metadata"`
+	Health    HealthState       `json:"Health"`
+	LeaseTTL  time.Duration     `json:"LeaseTTL"`
+	ExpiresAt time.Time         `json:"ExpiresAt"`
+	Weight    int               `json:"Weight,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler so Health is included in the wire
+// representation despite being stored in an atomic.Value.
+func (node *ServiceInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serviceInfoWire{
+		Name:      node.Name,
+		Version:   node.Version,
+		NodeID:    node.NodeID,
+		Endpoint:  node.Endpoint,
+		Metadata:  node.Metadata,
+		Health:    node.Health(),
+		LeaseTTL:  node.LeaseTTL,
+		ExpiresAt: node.ExpiresAt,
+		Weight:    node.Weight,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the counterpart to MarshalJSON.
+func (node *ServiceInfo) UnmarshalJSON(data []byte) error {
+	var wire serviceInfoWire
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	node.Name = wire.Name
+	node.Version = wire.Version
+	node.NodeID = wire.NodeID
+	node.Endpoint = wire.Endpoint
+	node.Metadata = wire.Metadata
+	node.LeaseTTL = wire.LeaseTTL
+	node.ExpiresAt = wire.ExpiresAt
+	node.Weight = wire.Weight
+	node.SetHealth(wire.Health)
+	return nil
+}
+
+// RegisterOptions controls lease behavior for RegisterService.
+type RegisterOptions struct {
+	// TTL is how long the registration is valid without a heartbeat. Zero
+	// means the service never expires (the pre-TTL behavior).
+	TTL time.Duration
+
+	// Weight is the node's relative share of traffic under the Weighted
+	// load-balancing strategy. Zero or negative means 1 (see
+	// ServiceInfo.EffectiveWeight); ignored by every other strategy.
+	Weight int
+}
+
+// serviceGroup holds every node registered under a single name-version key.
+type serviceGroup struct {
+	nodes []*ServiceInfo
+}
+
+// MemoryRegistry is a thread-safe, in-process implementation of
+// RegistryStore. It is the default backend and the one StartExpiryScanner
+// is built around; remote backends typically expire leases natively
+// (etcd leases, Consul TTL checks) instead of via a local scanner.
+type MemoryRegistry struct {
+	services map[string]*serviceGroup // Map of "name-version" to its nodes.
+	mu       sync.RWMutex             // Mutex to protect access to the services map.
+
+	watchMu  sync.Mutex
+	watchers map[string][]chan Event // Map of "name-version" to active watch channels.
+
+	stopExpiry chan struct{} // Closed by StartExpiryScanner's stop func to end the scanner goroutine.
+}
+
+var _ RegistryStore = (*MemoryRegistry)(nil)
+
+// NewMemoryRegistry creates and initializes a new in-process registry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		services: make(map[string]*serviceGroup),
+		watchers: make(map[string][]chan Event),
+	}
+}
+
+func serviceKey(name, version string) string {
+	return fmt.Sprintf("%s-%s", name, version)
+}
+
+// Register adds or updates a node under name-version. If nodeID matches a
+// node already registered under this service, the existing node is updated
+// and its lease refreshed instead of a new node being created, so clients
+// can re-register idempotently. If nodeID is empty, the endpoint is used as
+// the node's identity.
+func (r *MemoryRegistry) Register(name, version, endpoint string, metadata map[string]string, nodeID string, opts RegisterOptions) (*ServiceInfo, error) {
+	if nodeID == "" {
+		nodeID = endpoint
+	}
+
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	key := serviceKey(name, version)
+	group, exists := r.services[key]
+	if !exists {
+		group = &serviceGroup{}
+		r.services[key] = group
+	}
 
-	serviceKey := fmt.Sprintf("%s-%s", name, version)
-	if _, exists := r.services[serviceKey]; exists {
-		return nil, fmt.Errorf("service with name '%s' and version '%s' already registered", name, version)
+	var expiresAt time.Time
+	if opts.TTL > 0 {
+		expiresAt = time.Now().Add(opts.TTL)
 	}
 
-	proxyPort, err := r.allocatePort()
-	if err != nil {
-		return nil, fmt.Errorf("failed to allocate port for service '%s': %v", name, err)
+	for _, node := range group.nodes {
+		if node.NodeID == nodeID {
+			node.Endpoint = endpoint
+			node.Metadata = metadata
+			node.SetHealth(HealthPassing)
+			node.LeaseTTL = opts.TTL
+			node.ExpiresAt = expiresAt
+			node.Weight = opts.Weight
+			r.mu.Unlock()
+			r.publish(key, Event{Type: EventUpdate, Node: node})
+			return node, nil
+		}
 	}
 
-	serviceInfo := &ServiceInfo{
-		Name:     name,
-		Version:  version,
-		Endpoint: endpoint,
-		Metadata: metadata,
-		ProxyPort: proxyPort,
+	node := &ServiceInfo{
+		Name:      name,
+		Version:   version,
+		NodeID:    nodeID,
+		Endpoint:  endpoint,
+		Metadata:  metadata,
+		LeaseTTL:  opts.TTL,
+		ExpiresAt: expiresAt,
+		Weight:    opts.Weight,
 	}
-	r.services[serviceKey] = serviceInfo
-	return serviceInfo, nil
+	group.nodes = append(group.nodes, node)
+	r.mu.Unlock()
+
+	r.publish(key, Event{Type: EventCreate, Node: node})
+	return node, nil
 }
 
-// DeregisterService removes a service from the registry and marks its port as available.
-func (r *Registry) DeregisterService(name, version string) error {
+// Heartbeat refreshes the lease on an existing node, extending ExpiresAt by
+// its original LeaseTTL. Calling Heartbeat on a node that registered without
+// a TTL is a harmless no-op.
+func (r *MemoryRegistry) Heartbeat(name, version, nodeID string) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	node, err := r.findNodeLocked(name, version, nodeID)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+
+	if node.LeaseTTL > 0 {
+		node.ExpiresAt = time.Now().Add(node.LeaseTTL)
+	}
+	r.mu.Unlock()
 
-	serviceKey := fmt.Sprintf("%s-%s", name, version)
-	serviceInfo, exists := r.services[serviceKey]
+	r.publish(serviceKey(name, version), Event{Type: EventUpdate, Node: node})
+	return nil
+}
+
+// Deregister removes a single node from a service.
+func (r *MemoryRegistry) Deregister(name, version, nodeID string) error {
+	r.mu.Lock()
+	key := serviceKey(name, version)
+	group, exists := r.services[key]
 	if !exists {
+		r.mu.Unlock()
 		return fmt.Errorf("service with name '%s' and version '%s' not found", name, version)
 	}
 
-	delete(r.services, serviceKey)
-	r.releasePort(serviceInfo.ProxyPort)
+	idx := -1
+	for i, node := range group.nodes {
+		if node.NodeID == nodeID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		r.mu.Unlock()
+		return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+	}
+
+	removed := group.nodes[idx]
+	group.nodes = append(group.nodes[:idx], group.nodes[idx+1:]...)
+	if len(group.nodes) == 0 {
+		delete(r.services, key)
+	}
+	r.mu.Unlock()
+
+	r.publish(key, Event{Type: EventDelete, Node: removed})
 	return nil
 }
 
-// GetService retrieves the information for a specific service.
-func (r *Registry) GetService(name, version string) (*ServiceInfo, bool) {
+// Get returns every node registered for name-version.
+func (r *MemoryRegistry) Get(name, version string) ([]*ServiceInfo, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	serviceKey := fmt.Sprintf("%s-%s", name, version)
-	serviceInfo, exists := r.services[serviceKey]
-	return serviceInfo, exists
+	group, exists := r.services[serviceKey(name, version)]
+	if !exists {
+		return nil, false
+	}
+	nodes := make([]*ServiceInfo, len(group.nodes))
+	copy(nodes, group.nodes)
+	return nodes, true
 }
 
-// GetAllServices returns a snapshot of all registered services.
-func (r *Registry) GetAllServices() map[string]*ServiceInfo {
+// List returns a snapshot of every registered node, keyed by
+// "name-version".
+func (r *MemoryRegistry) List() map[string][]*ServiceInfo {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	services := make(map[string]*ServiceInfo)
-	for key, service := range r.services {
-		services[key] = service
+	services := make(map[string][]*ServiceInfo, len(r.services))
+	for key, group := range r.services {
+		nodes := make([]*ServiceInfo, len(group.nodes))
+		copy(nodes, group.nodes)
+		services[key] = nodes
 	}
 	return services
 }
 
-// allocatePort finds and reserves a unique port for a service proxy.
-func (r *Registry) allocatePort() (int, error) {
-	if r.portStart == 0 {
-		return 0, fmt.Errorf("port range not initialized")
+// Watch subscribes to Create/Update/Delete events for name-version. The
+// returned channel is closed once the returned unsubscribe function is
+// called. A caller that stops draining it before then only misses events:
+// once its buffer is full, publish drops them rather than blocking.
+func (r *MemoryRegistry) Watch(name, version string) (<-chan Event, func()) {
+	key := serviceKey(name, version)
+	ch := make(chan Event, watchBufferSize)
+
+	r.watchMu.Lock()
+	r.watchers[key] = append(r.watchers[key], ch)
+	r.watchMu.Unlock()
+
+	unsubscribe := func() {
+		r.watchMu.Lock()
+		defer r.watchMu.Unlock()
+		subs := r.watchers[key]
+		for i, sub := range subs {
+			if sub == ch {
+				r.watchers[key] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
 	}
+	return ch, unsubscribe
+}
+
+// publish fans an event out to every active watcher of key. Sends are
+// non-blocking: a watcher that isn't keeping up drops the event rather than
+// stalling registration for everyone else.
+func (r *MemoryRegistry) publish(key string, event Event) {
+	r.watchMu.Lock()
+	subs := r.watchers[key]
+	r.watchMu.Unlock()
 
-	for {
-		port := r.nextPort
-		r.nextPort++
-		if r.nextPort > 65535 {
-			r.nextPort = r.portStart // Wrap around if the range is exhausted (can be problematic in long run)
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Watch channel for '%s' is full; dropping %s event for node '%s'", key, event.Type, event.Node.NodeID)
 		}
-		if !r.portInUse[port] {
-			r.portInUse[port] = true
-			return port, nil
+	}
+}
+
+// findNodeLocked looks up a node by name, version and nodeID. Callers must
+// hold r.mu.
+func (r *MemoryRegistry) findNodeLocked(name, version, nodeID string) (*ServiceInfo, error) {
+	group, exists := r.services[serviceKey(name, version)]
+	if !exists {
+		return nil, fmt.Errorf("service with name '%s' and version '%s' not found", name, version)
+	}
+	for _, node := range group.nodes {
+		if node.NodeID == nodeID {
+			return node, nil
 		}
-		if port == r.nextPort { // Avoid infinite loop if all ports in range are used
-			return 0, fmt.Errorf("no available ports in the configured range")
+	}
+	return nil, fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+}
+
+// StartExpiryScanner launches a background goroutine that periodically
+// evicts nodes whose lease has elapsed. onExpire, if non-nil, is invoked for
+// each evicted node so callers can tear down its proxy once a service's last
+// node is gone. The returned stop function ends the scanner; call it at
+// most once.
+func (r *MemoryRegistry) StartExpiryScanner(interval time.Duration, onExpire func(*ServiceInfo)) (stop func()) {
+	r.stopExpiry = make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stopExpiry:
+				return
+			case <-ticker.C:
+				for _, expired := range r.evictExpired() {
+					log.Printf("Node '%s' of service '%s-%s' missed its TTL window; deregistering.", expired.NodeID, expired.Name, expired.Version)
+					if onExpire != nil {
+						onExpire(expired)
+					}
+				}
+			}
 		}
+	}()
+
+	return func() {
+		close(r.stopExpiry)
 	}
 }
 
-// releasePort marks a port as no longer in use.
-func (r *Registry) releasePort(port int) {
-	delete(r.portInUse, port)
-}
\ No newline at end of file
+// evictExpired removes, publishes Delete events for, and returns every node
+// whose ExpiresAt has passed. Nodes with a zero ExpiresAt (no TTL) are never
+// evicted.
+func (r *MemoryRegistry) evictExpired() []*ServiceInfo {
+	r.mu.Lock()
+	now := time.Now()
+	var expired []*ServiceInfo
+	for key, group := range r.services {
+		remaining := group.nodes[:0]
+		for _, node := range group.nodes {
+			if !node.ExpiresAt.IsZero() && node.ExpiresAt.Before(now) {
+				expired = append(expired, node)
+				continue
+			}
+			remaining = append(remaining, node)
+		}
+		group.nodes = remaining
+		if len(group.nodes) == 0 {
+			delete(r.services, key)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, node := range expired {
+		r.publish(serviceKey(node.Name, node.Version), Event{Type: EventDelete, Node: node})
+	}
+	return expired
+}
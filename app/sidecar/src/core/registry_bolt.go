@@ -0,0 +1,217 @@
+// core/registry_bolt.go
+// BoltRegistry persists service data to a local BoltDB file, so a sidecar
+// that crashes or restarts can recover every registration on its own
+// instead of waiting for clients to notice and re-register, unlike
+// MemoryRegistry which only lives for the life of the process. It has no
+// native change-notification mechanism, so Watch is implemented by polling
+// and diffing, the same approach registry_redis.go uses for creates and
+// updates. Requires go.etcd.io/bbolt as a dependency.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// servicesBucket is the single BoltDB bucket BoltRegistry stores nodes in.
+var servicesBucket = []byte("services")
+
+var _ RegistryStore = (*BoltRegistry)(nil)
+
+// BoltRegistry is a RegistryStore backed by a local BoltDB file. Each node
+// is stored under key serviceKey(name, version)+"/"+nodeID, JSON-encoded
+// the same way the etcd, Consul and Redis adapters encode theirs.
+type BoltRegistry struct {
+	db *bolt.DB
+}
+
+// NewBoltRegistry opens (creating if needed) a BoltDB file at path and
+// ensures its services bucket exists.
+func NewBoltRegistry(path string) (*BoltRegistry, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("bolt registry: failed to open %q: %w", path, err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(servicesBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("bolt registry: failed to create bucket: %w", err)
+	}
+
+	return &BoltRegistry{db: db}, nil
+}
+
+func (b *BoltRegistry) nodeKey(name, version, nodeID string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", serviceKey(name, version), nodeID))
+}
+
+// Register stores the node's JSON encoding under its key, overwriting
+// whatever was stored for this nodeID before (re-registration or heartbeat).
+func (b *BoltRegistry) Register(name, version, endpoint string, metadata map[string]string, nodeID string, opts RegisterOptions) (*ServiceInfo, error) {
+	if nodeID == "" {
+		nodeID = endpoint
+	}
+
+	var expiresAt time.Time
+	if opts.TTL > 0 {
+		expiresAt = time.Now().Add(opts.TTL)
+	}
+
+	node := &ServiceInfo{
+		Name:      name,
+		Version:   version,
+		NodeID:    nodeID,
+		Endpoint:  endpoint,
+		Metadata:  metadata,
+		LeaseTTL:  opts.TTL,
+		ExpiresAt: expiresAt,
+		Weight:    opts.Weight,
+	}
+
+	payload, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("bolt registry: failed to marshal node: %w", err)
+	}
+
+	key := b.nodeKey(name, version, nodeID)
+	if err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(servicesBucket).Put(key, payload)
+	}); err != nil {
+		return nil, fmt.Errorf("bolt registry: failed to store node: %w", err)
+	}
+	return node, nil
+}
+
+// Deregister removes the node's key.
+func (b *BoltRegistry) Deregister(name, version, nodeID string) error {
+	key := b.nodeKey(name, version, nodeID)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(servicesBucket)
+		if bucket.Get(key) == nil {
+			return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+		}
+		return bucket.Delete(key)
+	})
+}
+
+// Heartbeat re-registers the node to refresh its persisted ExpiresAt.
+func (b *BoltRegistry) Heartbeat(name, version, nodeID string) error {
+	nodes, ok := b.Get(name, version)
+	if !ok {
+		return fmt.Errorf("service with name '%s' and version '%s' not found", name, version)
+	}
+	for _, node := range nodes {
+		if node.NodeID == nodeID {
+			_, err := b.Register(name, version, node.Endpoint, node.Metadata, nodeID, RegisterOptions{TTL: node.LeaseTTL, Weight: node.Weight})
+			return err
+		}
+	}
+	return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+}
+
+// Get returns every node stored under name-version's key prefix.
+func (b *BoltRegistry) Get(name, version string) ([]*ServiceInfo, bool) {
+	prefix := []byte(serviceKey(name, version) + "/")
+	var nodes []*ServiceInfo
+
+	b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(servicesBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var node ServiceInfo
+			if err := json.Unmarshal(v, &node); err != nil {
+				continue
+			}
+			nodes = append(nodes, &node)
+		}
+		return nil
+	})
+
+	if len(nodes) == 0 {
+		return nil, false
+	}
+	return nodes, true
+}
+
+// List decodes every persisted node, grouped by "name-version". Called on
+// startup by ProxyManager.RestoreProxies to re-establish proxies for
+// whatever survived the restart.
+func (b *BoltRegistry) List() map[string][]*ServiceInfo {
+	services := make(map[string][]*ServiceInfo)
+
+	b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(servicesBucket).ForEach(func(k, v []byte) error {
+			var node ServiceInfo
+			if err := json.Unmarshal(v, &node); err != nil {
+				return nil
+			}
+			key := serviceKey(node.Name, node.Version)
+			services[key] = append(services[key], &node)
+			return nil
+		})
+	})
+
+	return services
+}
+
+// Watch polls name-version's nodes every 2 seconds and compares them with
+// the previous poll to synthesize events, since BoltDB has no native change
+// notification to subscribe to: Create for a node not seen before, Delete
+// for one that is gone, and Update for every node still present, whether or
+// not it changed.
+func (b *BoltRegistry) Watch(name, version string) (<-chan Event, func()) {
+	ch := make(chan Event, watchBufferSize)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		seen := make(map[string]*ServiceInfo)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current, _ := b.Get(name, version)
+				next := make(map[string]*ServiceInfo, len(current))
+				for _, node := range current {
+					next[node.NodeID] = node
+					eventType := EventCreate
+					if _, existed := seen[node.NodeID]; existed {
+						eventType = EventUpdate
+					}
+					select {
+					case ch <- Event{Type: eventType, Node: node}:
+					default:
+					}
+				}
+				for nodeID, node := range seen {
+					if _, stillPresent := next[nodeID]; !stillPresent {
+						select {
+						case ch <- Event{Type: EventDelete, Node: node}:
+						default:
+						}
+					}
+				}
+				seen = next
+			}
+		}
+	}()
+
+	return ch, func() { close(stop) }
+}
+
+// Close closes the underlying BoltDB file. Call it during shutdown.
+func (b *BoltRegistry) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,203 @@
+// core/registry_consul.go
+// ConsulRegistry stores service data in Consul's catalog, piggybacking on
+// Consul's own TTL health checks instead of the local expiry scanner.
+// Requires github.com/hashicorp/consul/api as a dependency.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+var _ RegistryStore = (*ConsulRegistry)(nil)
+
+// ConsulRegistry is a RegistryStore backed by a Consul agent. Each node is
+// registered as a Consul service instance whose ID is nodeID and whose
+// Meta carries the node's Metadata plus a JSON-encoded ServiceInfo, so Get
+// and List can reconstruct the full struct without a second lookup.
+type ConsulRegistry struct {
+	client *consul.Client
+}
+
+// NewConsulRegistry creates a ConsulRegistry using client for storage.
+func NewConsulRegistry(client *consul.Client) *ConsulRegistry {
+	return &ConsulRegistry{client: client}
+}
+
+const consulServiceInfoMetaKey = "icaht_service_info"
+
+// Register registers the node with Consul's agent catalog. When opts.TTL is
+// set, it also registers a TTL health check that Heartbeat passes, so Consul
+// marks the node critical if heartbeats stop arriving, and removes it once
+// it has been critical for 3x the TTL (DeregisterCriticalServiceAfter).
+func (c *ConsulRegistry) Register(name, version, endpoint string, metadata map[string]string, nodeID string, opts RegisterOptions) (*ServiceInfo, error) {
+	if nodeID == "" {
+		nodeID = endpoint
+	}
+
+	var expiresAt time.Time
+	if opts.TTL > 0 {
+		expiresAt = time.Now().Add(opts.TTL)
+	}
+
+	node := &ServiceInfo{
+		Name:      name,
+		Version:   version,
+		NodeID:    nodeID,
+		Endpoint:  endpoint,
+		Metadata:  metadata,
+		LeaseTTL:  opts.TTL,
+		ExpiresAt: expiresAt,
+		Weight:    opts.Weight,
+	}
+
+	payload, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("consul registry: failed to marshal node: %w", err)
+	}
+	meta := map[string]string{consulServiceInfoMetaKey: string(payload)}
+
+	registration := &consul.AgentServiceRegistration{
+		ID:      nodeID,
+		Name:    serviceKey(name, version),
+		Address: endpoint,
+		Meta:    meta,
+	}
+	if opts.TTL > 0 {
+		registration.Check = &consul.AgentServiceCheck{
+			TTL:                            opts.TTL.String(),
+			DeregisterCriticalServiceAfter: (opts.TTL * 3).String(),
+		}
+	}
+
+	if err := c.client.Agent().ServiceRegister(registration); err != nil {
+		return nil, fmt.Errorf("consul registry: failed to register service: %w", err)
+	}
+	return node, nil
+}
+
+// Deregister removes the node from Consul's catalog.
+func (c *ConsulRegistry) Deregister(name, version, nodeID string) error {
+	if err := c.client.Agent().ServiceDeregister(nodeID); err != nil {
+		return fmt.Errorf("consul registry: failed to deregister node '%s': %w", nodeID, err)
+	}
+	return nil
+}
+
+// Heartbeat marks the node's TTL check as passing. A node registered
+// without a TTL has no such check, so for it this is a no-op.
+func (c *ConsulRegistry) Heartbeat(name, version, nodeID string) error {
+	nodes, _ := c.Get(name, version)
+	for _, node := range nodes {
+		if node.NodeID == nodeID && node.LeaseTTL == 0 {
+			return nil
+		}
+	}
+	if err := c.client.Agent().PassTTL("service:"+nodeID, "heartbeat"); err != nil {
+		return fmt.Errorf("consul registry: failed to pass TTL check for node '%s': %w", nodeID, err)
+	}
+	return nil
+}
+
+// Get returns every node Consul has registered under name-version.
+func (c *ConsulRegistry) Get(name, version string) ([]*ServiceInfo, bool) {
+	services, err := c.client.Agent().Services()
+	if err != nil {
+		return nil, false
+	}
+
+	key := serviceKey(name, version)
+	var nodes []*ServiceInfo
+	for _, svc := range services {
+		if svc.Service != key {
+			continue
+		}
+		if node := decodeConsulServiceInfo(svc); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, len(nodes) > 0
+}
+
+// List returns every node Consul has registered, grouped by "name-version".
+func (c *ConsulRegistry) List() map[string][]*ServiceInfo {
+	services := make(map[string][]*ServiceInfo)
+	agentServices, err := c.client.Agent().Services()
+	if err != nil {
+		return services
+	}
+	for _, svc := range agentServices {
+		node := decodeConsulServiceInfo(svc)
+		if node == nil {
+			continue
+		}
+		key := serviceKey(node.Name, node.Version)
+		services[key] = append(services[key], node)
+	}
+	return services
+}
+
+func decodeConsulServiceInfo(svc *consul.AgentService) *ServiceInfo {
+	payload, ok := svc.Meta[consulServiceInfoMetaKey]
+	if !ok {
+		return nil
+	}
+	var node ServiceInfo
+	if err := json.Unmarshal([]byte(payload), &node); err != nil {
+		return nil
+	}
+	return &node
+}
+
+// Watch polls the local Consul agent's service list every 2 seconds and
+// compares name-version's nodes with the previous poll: Create for a node
+// not seen before, Delete for one that is gone, and Update for every node
+// still present, whether or not it changed. It does not use Consul's
+// blocking queries.
+func (c *ConsulRegistry) Watch(name, version string) (<-chan Event, func()) {
+	ch := make(chan Event, watchBufferSize)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(ch)
+		seen := make(map[string]*ServiceInfo)
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current, _ := c.Get(name, version)
+				next := make(map[string]*ServiceInfo, len(current))
+				for _, node := range current {
+					next[node.NodeID] = node
+					eventType := EventCreate
+					if _, existed := seen[node.NodeID]; existed {
+						eventType = EventUpdate
+					}
+					select {
+					case ch <- Event{Type: eventType, Node: node}:
+					default:
+					}
+				}
+				for nodeID, node := range seen {
+					if _, stillPresent := next[nodeID]; !stillPresent {
+						select {
+						case ch <- Event{Type: EventDelete, Node: node}:
+						default:
+						}
+					}
+				}
+				seen = next
+			}
+		}
+	}()
+
+	return ch, func() { close(stop) }
+}
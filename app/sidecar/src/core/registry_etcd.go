@@ -0,0 +1,196 @@
+// core/registry_etcd.go
+// EtcdRegistry stores service data in etcd so every sidecar in a cluster
+// sees the same registrations, instead of each holding its own in-process
+// map. Requires go.etcd.io/etcd/client/v3 as a dependency; operators that
+// don't need a shared control plane can keep using MemoryRegistry.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ RegistryStore = (*EtcdRegistry)(nil)
+
+// EtcdRegistry is a RegistryStore backed by an etcd cluster. Each node is
+// stored as a key under Prefix+"/"+name-version+"/"+nodeID, leased with
+// etcd's native TTL so an instance that crashes without deregistering is
+// cleaned up by etcd itself rather than a local expiry scanner.
+type EtcdRegistry struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcdRegistry creates an EtcdRegistry using client for storage. prefix
+// namespaces all keys this registry reads and writes (e.g. "/icaht/services").
+func NewEtcdRegistry(client *clientv3.Client, prefix string) *EtcdRegistry {
+	return &EtcdRegistry{client: client, prefix: prefix}
+}
+
+func (e *EtcdRegistry) nodeKey(name, version, nodeID string) string {
+	return fmt.Sprintf("%s/%s/%s", e.prefix, serviceKey(name, version), nodeID)
+}
+
+// Register stores the node under a lease matching opts.TTL (or no lease, if
+// TTL is zero), so etcd evicts it automatically if the sidecar that owns it
+// stops renewing the lease.
+func (e *EtcdRegistry) Register(name, version, endpoint string, metadata map[string]string, nodeID string, opts RegisterOptions) (*ServiceInfo, error) {
+	if nodeID == "" {
+		nodeID = endpoint
+	}
+
+	var expiresAt time.Time
+	var leaseID clientv3.LeaseID
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if opts.TTL > 0 {
+		lease, err := e.client.Grant(ctx, int64(opts.TTL.Seconds()))
+		if err != nil {
+			return nil, fmt.Errorf("etcd registry: failed to grant lease: %w", err)
+		}
+		leaseID = lease.ID
+		expiresAt = time.Now().Add(opts.TTL)
+	}
+
+	node := &ServiceInfo{
+		Name:      name,
+		Version:   version,
+		NodeID:    nodeID,
+		Endpoint:  endpoint,
+		Metadata:  metadata,
+		LeaseTTL:  opts.TTL,
+		ExpiresAt: expiresAt,
+		Weight:    opts.Weight,
+	}
+
+	payload, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("etcd registry: failed to marshal node: %w", err)
+	}
+
+	opsList := []clientv3.OpOption{}
+	if leaseID != 0 {
+		opsList = append(opsList, clientv3.WithLease(leaseID))
+	}
+	if _, err := e.client.Put(ctx, e.nodeKey(name, version, nodeID), string(payload), opsList...); err != nil {
+		return nil, fmt.Errorf("etcd registry: failed to put node: %w", err)
+	}
+	return node, nil
+}
+
+// Deregister removes the node's key from etcd.
+func (e *EtcdRegistry) Deregister(name, version, nodeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := e.client.Delete(ctx, e.nodeKey(name, version, nodeID))
+	if err != nil {
+		return fmt.Errorf("etcd registry: failed to delete node: %w", err)
+	}
+	if resp.Deleted == 0 {
+		return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+	}
+	return nil
+}
+
+// Heartbeat renews the node's lease by re-putting it with the TTL reset.
+// etcd's own KeepAlive API is a better fit for long-lived processes, but
+// re-registering on each heartbeat keeps this adapter's shape identical to
+// MemoryRegistry's.
+func (e *EtcdRegistry) Heartbeat(name, version, nodeID string) error {
+	nodes, ok := e.Get(name, version)
+	if !ok {
+		return fmt.Errorf("service with name '%s' and version '%s' not found", name, version)
+	}
+	for _, node := range nodes {
+		if node.NodeID == nodeID {
+			_, err := e.Register(name, version, node.Endpoint, node.Metadata, nodeID, RegisterOptions{TTL: node.LeaseTTL, Weight: node.Weight})
+			return err
+		}
+	}
+	return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+}
+
+// Get fetches every node currently stored under name-version.
+func (e *EtcdRegistry) Get(name, version string) ([]*ServiceInfo, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := e.client.Get(ctx, fmt.Sprintf("%s/%s/", e.prefix, serviceKey(name, version)), clientv3.WithPrefix())
+	if err != nil || len(resp.Kvs) == 0 {
+		return nil, false
+	}
+
+	nodes := make([]*ServiceInfo, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var node ServiceInfo
+		if err := json.Unmarshal(kv.Value, &node); err != nil {
+			continue
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes, true
+}
+
+// List fetches every node stored under prefix, grouped by "name-version".
+func (e *EtcdRegistry) List() map[string][]*ServiceInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	services := make(map[string][]*ServiceInfo)
+	resp, err := e.client.Get(ctx, e.prefix+"/", clientv3.WithPrefix())
+	if err != nil {
+		return services
+	}
+	for _, kv := range resp.Kvs {
+		var node ServiceInfo
+		if err := json.Unmarshal(kv.Value, &node); err != nil {
+			continue
+		}
+		key := serviceKey(node.Name, node.Version)
+		services[key] = append(services[key], &node)
+	}
+	return services
+}
+
+// Watch subscribes to etcd's native watch stream for name-version's key
+// prefix and translates PUT/DELETE events into RegistryStore Events.
+func (e *EtcdRegistry) Watch(name, version string) (<-chan Event, func()) {
+	ch := make(chan Event, watchBufferSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	watchChan := e.client.Watch(ctx, fmt.Sprintf("%s/%s/", e.prefix, serviceKey(name, version)), clientv3.WithPrefix(), clientv3.WithPrevKV())
+
+	go func() {
+		defer close(ch)
+		for resp := range watchChan {
+			for _, ev := range resp.Events {
+				var node ServiceInfo
+				eventType := EventDelete
+				if ev.Type == clientv3.EventTypePut {
+					if err := json.Unmarshal(ev.Kv.Value, &node); err != nil {
+						continue
+					}
+					eventType = EventCreate
+					if ev.IsModify() {
+						eventType = EventUpdate
+					}
+				} else if err := json.Unmarshal(ev.PrevKv.Value, &node); err != nil {
+					continue
+				}
+
+				select {
+				case ch <- Event{Type: eventType, Node: &node}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return ch, cancel
+}
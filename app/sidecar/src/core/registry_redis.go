@@ -0,0 +1,237 @@
+// core/registry_redis.go
+// RedisRegistry stores service data in Redis, using a hash per service plus
+// per-node lease keys with a TTL that Redis expires itself. Only the lease
+// key expires: the node's hash field stays, so Get and List keep returning
+// a node after its lease has lapsed until it is deregistered. Requires
+// github.com/redis/go-redis/v9 as a dependency.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var _ RegistryStore = (*RedisRegistry)(nil)
+
+// RedisRegistry is a RegistryStore backed by a Redis instance. Each node is
+// stored as a hash field under key Prefix+":"+name-version, value the
+// JSON-encoded ServiceInfo; a companion key Prefix+":"+name-version+":"+
+// nodeID carries the TTL and is watched via keyspace notifications to
+// detect expiry.
+type RedisRegistry struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRegistry creates a RedisRegistry using client for storage. prefix
+// namespaces all keys this registry reads and writes (e.g. "icaht:services").
+func NewRedisRegistry(client *redis.Client, prefix string) *RedisRegistry {
+	return &RedisRegistry{client: client, prefix: prefix}
+}
+
+func (rr *RedisRegistry) hashKey(name, version string) string {
+	return fmt.Sprintf("%s:%s", rr.prefix, serviceKey(name, version))
+}
+
+func (rr *RedisRegistry) leaseKey(name, version, nodeID string) string {
+	return fmt.Sprintf("%s:%s:%s", rr.prefix, serviceKey(name, version), nodeID)
+}
+
+// Register stores the node and, when opts.TTL is set, a companion lease key
+// with matching expiry, whose expiry Watch reports via keyspace
+// notifications.
+func (rr *RedisRegistry) Register(name, version, endpoint string, metadata map[string]string, nodeID string, opts RegisterOptions) (*ServiceInfo, error) {
+	if nodeID == "" {
+		nodeID = endpoint
+	}
+
+	var expiresAt time.Time
+	if opts.TTL > 0 {
+		expiresAt = time.Now().Add(opts.TTL)
+	}
+
+	node := &ServiceInfo{
+		Name:      name,
+		Version:   version,
+		NodeID:    nodeID,
+		Endpoint:  endpoint,
+		Metadata:  metadata,
+		LeaseTTL:  opts.TTL,
+		ExpiresAt: expiresAt,
+		Weight:    opts.Weight,
+	}
+
+	payload, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("redis registry: failed to marshal node: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := rr.client.HSet(ctx, rr.hashKey(name, version), nodeID, payload).Err(); err != nil {
+		return nil, fmt.Errorf("redis registry: failed to store node: %w", err)
+	}
+	if opts.TTL > 0 {
+		if err := rr.client.Set(ctx, rr.leaseKey(name, version, nodeID), "1", opts.TTL).Err(); err != nil {
+			return nil, fmt.Errorf("redis registry: failed to set lease key: %w", err)
+		}
+	}
+	return node, nil
+}
+
+// Deregister removes the node's hash field and lease key.
+func (rr *RedisRegistry) Deregister(name, version, nodeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	removed, err := rr.client.HDel(ctx, rr.hashKey(name, version), nodeID).Result()
+	if err != nil {
+		return fmt.Errorf("redis registry: failed to remove node: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+	}
+	rr.client.Del(ctx, rr.leaseKey(name, version, nodeID))
+	return nil
+}
+
+// Heartbeat re-registers the node to refresh its lease key's TTL.
+func (rr *RedisRegistry) Heartbeat(name, version, nodeID string) error {
+	nodes, ok := rr.Get(name, version)
+	if !ok {
+		return fmt.Errorf("service with name '%s' and version '%s' not found", name, version)
+	}
+	for _, node := range nodes {
+		if node.NodeID == nodeID {
+			_, err := rr.Register(name, version, node.Endpoint, node.Metadata, nodeID, RegisterOptions{TTL: node.LeaseTTL, Weight: node.Weight})
+			return err
+		}
+	}
+	return fmt.Errorf("node '%s' not found for service '%s-%s'", nodeID, name, version)
+}
+
+// Get returns every node stored in the service's hash.
+func (rr *RedisRegistry) Get(name, version string) ([]*ServiceInfo, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	values, err := rr.client.HGetAll(ctx, rr.hashKey(name, version)).Result()
+	if err != nil || len(values) == 0 {
+		return nil, false
+	}
+
+	nodes := make([]*ServiceInfo, 0, len(values))
+	for _, payload := range values {
+		var node ServiceInfo
+		if err := json.Unmarshal([]byte(payload), &node); err != nil {
+			continue
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes, true
+}
+
+// List scans for every service hash under prefix and decodes its nodes.
+func (rr *RedisRegistry) List() map[string][]*ServiceInfo {
+	services := make(map[string][]*ServiceInfo)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	iter := rr.client.Scan(ctx, 0, rr.prefix+":*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		values, err := rr.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+		for _, payload := range values {
+			var node ServiceInfo
+			if err := json.Unmarshal([]byte(payload), &node); err != nil {
+				continue
+			}
+			groupKey := serviceKey(node.Name, node.Version)
+			services[groupKey] = append(services[groupKey], &node)
+		}
+	}
+	return services
+}
+
+// Watch subscribes to Redis keyspace notifications for expired lease keys
+// under name-version, combined with periodic polling of the service hash to
+// catch creates, updates and deletes. Keyspace notifications must be
+// enabled on the server (notify-keyspace-events Ex) for expiry events to
+// arrive.
+func (rr *RedisRegistry) Watch(name, version string) (<-chan Event, func()) {
+	ch := make(chan Event, watchBufferSize)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pattern := fmt.Sprintf("__keyevent@0__:expired")
+	pubsub := rr.client.PSubscribe(ctx, pattern)
+
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
+		seen := make(map[string]*ServiceInfo)
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		leasePrefix := rr.hashKey(name, version) + ":"
+		msgs := pubsub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-msgs:
+				if msg == nil {
+					return
+				}
+				expiredKey := msg.Payload
+				if !strings.HasPrefix(expiredKey, leasePrefix) {
+					continue
+				}
+				nodeID := strings.TrimPrefix(expiredKey, leasePrefix)
+				if node, ok := seen[nodeID]; ok {
+					delete(seen, nodeID)
+					select {
+					case ch <- Event{Type: EventDelete, Node: node}:
+					default:
+					}
+				}
+			case <-ticker.C:
+				current, _ := rr.Get(name, version)
+				next := make(map[string]*ServiceInfo, len(current))
+				for _, node := range current {
+					next[node.NodeID] = node
+					eventType := EventCreate
+					if _, existed := seen[node.NodeID]; existed {
+						eventType = EventUpdate
+					}
+					select {
+					case ch <- Event{Type: eventType, Node: node}:
+					default:
+					}
+				}
+				for nodeID, node := range seen {
+					if _, stillPresent := next[nodeID]; !stillPresent {
+						select {
+						case ch <- Event{Type: EventDelete, Node: node}:
+						default:
+						}
+					}
+				}
+				seen = next
+			}
+		}
+	}()
+
+	return ch, cancel
+}
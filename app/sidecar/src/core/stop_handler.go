@@ -0,0 +1,71 @@
+// core/stop_handler.go
+// This file defines the HTTP handler backing the sidecar CLI's `stop`
+// subcommand: an operator-triggered teardown of a service's nodes and its
+// shared proxy, as opposed to the node self-deregistration that
+// HeartbeatHandler's lease expiry already handles.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StopRequest names the service to tear down.
+type StopRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// StopHandler handles POST /stop: it deregisters every node of a service
+// and stops its shared proxy.
+type StopHandler struct {
+	registry     RegistryStore
+	proxyManager *ProxyManager
+}
+
+// NewStopHandler creates a new StopHandler.
+func NewStopHandler(registry RegistryStore, proxyManager *ProxyManager) *StopHandler {
+	return &StopHandler{registry: registry, proxyManager: proxyManager}
+}
+
+// ServeHTTP implements the http.Handler interface for the stop endpoint.
+func (h *StopHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req StopRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name == "" || req.Version == "" {
+		http.Error(w, "Missing required fields (name, version)", http.StatusBadRequest)
+		return
+	}
+
+	nodes, ok := h.registry.Get(req.Name, req.Version)
+	if !ok {
+		http.Error(w, fmt.Sprintf("service '%s-%s' not found", req.Name, req.Version), http.StatusNotFound)
+		return
+	}
+
+	for _, node := range nodes {
+		if err := h.registry.Deregister(req.Name, req.Version, node.NodeID); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to deregister node '%s': %v", node.NodeID, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := h.proxyManager.StopProxy(req.Name, req.Version); err != nil {
+		http.Error(w, fmt.Sprintf("Deregistered service '%s-%s' but failed to stop its proxy: %v", req.Name, req.Version, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
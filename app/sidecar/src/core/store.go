@@ -0,0 +1,56 @@
+// core/store.go
+// This file defines RegistryStore, the storage-agnostic contract that the
+// registration API and proxy manager depend on. MemoryRegistry is the
+// default, in-process implementation; registry_etcd.go, registry_consul.go
+// and registry_redis.go provide adapters for running the control plane's
+// service data in a shared backend across a multi-node cluster.
+
+package core
+
+// EventType classifies a change reported by RegistryStore.Watch.
+type EventType string
+
+const (
+	// EventCreate is emitted when a new node registers.
+	EventCreate EventType = "create"
+	// EventUpdate is emitted when an existing node re-registers or sends a heartbeat.
+	EventUpdate EventType = "update"
+	// EventDelete is emitted when a node is deregistered or its lease expires.
+	EventDelete EventType = "delete"
+)
+
+// Event describes a single change to a service's node list.
+type Event struct {
+	Type EventType
+	Node *ServiceInfo
+}
+
+// RegistryStore is the storage backend for service registration data. It is
+// deliberately narrow so remote backends (etcd, Consul, Redis) can implement
+// it without taking on concerns, like local proxy port allocation or
+// load-balancing cursor state, that only make sense for the sidecar that
+// owns them; those live in ProxyManager instead.
+type RegistryStore interface {
+	// Register adds or updates a node under name-version, keyed by nodeID.
+	// If nodeID is empty, the endpoint is used as the node's identity.
+	Register(name, version, endpoint string, metadata map[string]string, nodeID string, opts RegisterOptions) (*ServiceInfo, error)
+
+	// Deregister removes a single node from a service.
+	Deregister(name, version, nodeID string) error
+
+	// Heartbeat refreshes a node's lease, extending ExpiresAt by its
+	// original LeaseTTL. A no-op for nodes registered without a TTL.
+	Heartbeat(name, version, nodeID string) error
+
+	// Get returns every node registered for name-version.
+	Get(name, version string) ([]*ServiceInfo, bool)
+
+	// List returns a snapshot of every registered node, keyed by
+	// "name-version".
+	List() map[string][]*ServiceInfo
+
+	// Watch subscribes to Create/Update/Delete events for name-version.
+	// The returned channel is closed, and should no longer be read from,
+	// once the returned unsubscribe function is called.
+	Watch(name, version string) (<-chan Event, func())
+}
@@ -0,0 +1,390 @@
+// core/tunnel.go
+// This file implements the reverse-tunnel transport used by nodes behind a
+// NAT or firewall that cannot advertise a reachable Endpoint. Such a node
+// opens a long-lived connection to /register/tunnel (see
+// tunnel_registration_handler.go) instead; that connection is hijacked into
+// a TunnelConn, which multiplexes many concurrent request/response streams
+// over it using a small framed protocol. ProxyManager routes traffic to a
+// tunneled node by opening a stream and writing the HTTP request to it,
+// exactly as if it had dialed the node directly.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// frameFlag describes what a frame carries, so many streams' worth of
+// request/response bytes can share one tunnel connection.
+type frameFlag byte
+
+const (
+	// flagOpen starts a new stream; its payload is always empty.
+	flagOpen frameFlag = iota + 1
+	// flagData carries a chunk of the stream's body.
+	flagData
+	// flagClose ends a stream; no more frames follow for its ID.
+	flagClose
+)
+
+// frameHeaderSize is the encoded size of a frame's header: a 4-byte stream
+// ID, a 1-byte flag, and a 4-byte payload length, all big-endian.
+const frameHeaderSize = 4 + 1 + 4
+
+// maxFramePayload bounds a single frame's payload so one stream can't hold
+// the tunnel's reader hostage; larger writes are split across frames.
+const maxFramePayload = 32 * 1024
+
+// streamBufferSize is how many pending data frames a stream buffers before
+// TunnelConn.readLoop blocks waiting for the reader to keep up.
+const streamBufferSize = 16
+
+type frame struct {
+	streamID uint32
+	flag     frameFlag
+	payload  []byte
+}
+
+func writeFrame(w io.Writer, f frame) error {
+	header := make([]byte, frameHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], f.streamID)
+	header[4] = byte(f.flag)
+	binary.BigEndian.PutUint32(header[5:9], uint32(len(f.payload)))
+
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("writing frame header: %w", err)
+	}
+	if len(f.payload) == 0 {
+		return nil
+	}
+	if _, err := w.Write(f.payload); err != nil {
+		return fmt.Errorf("writing frame payload: %w", err)
+	}
+	return nil
+}
+
+func readFrame(r io.Reader) (frame, error) {
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{
+		streamID: binary.BigEndian.Uint32(header[0:4]),
+		flag:     frameFlag(header[4]),
+	}
+	length := binary.BigEndian.Uint32(header[5:9])
+	if length > maxFramePayload {
+		return frame{}, fmt.Errorf("frame payload of %d bytes exceeds max %d", length, maxFramePayload)
+	}
+	if length > 0 {
+		f.payload = make([]byte, length)
+		if _, err := io.ReadFull(r, f.payload); err != nil {
+			return frame{}, fmt.Errorf("reading frame payload: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// tunnelStream is one multiplexed request/response pair inside a TunnelConn.
+// It implements io.ReadWriteCloser so http.Request.Write and
+// http.ReadResponse can use it exactly like a dialed net.Conn.
+type tunnelStream struct {
+	id   uint32
+	conn *TunnelConn
+
+	incoming chan []byte  // Data frames delivered by TunnelConn.readLoop.
+	buf      bytes.Buffer // Bytes from a partially-read frame not yet returned to the caller.
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newTunnelStream(id uint32, conn *TunnelConn) *tunnelStream {
+	return &tunnelStream{
+		id:       id,
+		conn:     conn,
+		incoming: make(chan []byte, streamBufferSize),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (s *tunnelStream) Read(p []byte) (int, error) {
+	for s.buf.Len() == 0 {
+		select {
+		case payload, ok := <-s.incoming:
+			if !ok {
+				return 0, io.EOF
+			}
+			s.buf.Write(payload)
+			continue
+		default:
+		}
+
+		select {
+		case payload, ok := <-s.incoming:
+			if !ok {
+				return 0, io.EOF
+			}
+			s.buf.Write(payload)
+		case <-s.closed:
+			// s.closed can fire while frames readLoop already delivered
+			// are still sitting in s.incoming, so a close frame racing
+			// with unread buffered data must not win: drain one more
+			// frame non-blockingly before honoring the close.
+			select {
+			case payload, ok := <-s.incoming:
+				if !ok {
+					return 0, io.EOF
+				}
+				s.buf.Write(payload)
+			default:
+				return 0, io.EOF
+			}
+		}
+	}
+	return s.buf.Read(p)
+}
+
+func (s *tunnelStream) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxFramePayload {
+			chunk = chunk[:maxFramePayload]
+		}
+		if err := s.conn.writeFrame(frame{streamID: s.id, flag: flagData, payload: chunk}); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// closeLocal tears down the stream's local bookkeeping without notifying
+// the peer; used when the peer closed it first or the tunnel itself died.
+func (s *tunnelStream) closeLocal() {
+	s.once.Do(func() {
+		close(s.closed)
+		s.conn.removeStream(s.id)
+	})
+}
+
+// Close ends the stream and tells the peer, so it can stop waiting on it.
+func (s *tunnelStream) Close() error {
+	s.closeLocal()
+	return s.conn.writeFrame(frame{streamID: s.id, flag: flagClose})
+}
+
+// TunnelConn is the sidecar's side of a reverse tunnel: a hijacked
+// connection from a node that registered via /register/tunnel because it
+// has no reachable Endpoint. ProxyManager opens one stream per inbound
+// proxied request over it instead of dialing the node directly.
+type TunnelConn struct {
+	conn net.Conn
+
+	writeMu sync.Mutex // Serializes frame writes; the single readLoop needs no lock.
+
+	streamsMu sync.Mutex
+	streams   map[uint32]*tunnelStream
+	nextID    uint32
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newTunnelConn(conn net.Conn) *TunnelConn {
+	t := &TunnelConn{
+		conn:    conn,
+		streams: make(map[uint32]*tunnelStream),
+		closed:  make(chan struct{}),
+	}
+	go t.readLoop()
+	return t
+}
+
+// readLoop demultiplexes incoming frames onto their stream until the
+// connection errors or is closed, then tears every open stream down.
+func (t *TunnelConn) readLoop() {
+	defer t.Close()
+
+	for {
+		f, err := readFrame(t.conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Tunnel read error: %v", err)
+			}
+			return
+		}
+
+		t.streamsMu.Lock()
+		stream, ok := t.streams[f.streamID]
+		t.streamsMu.Unlock()
+		if !ok {
+			continue // Frame for an unknown or already-closed stream; drop it.
+		}
+
+		switch f.flag {
+		case flagData:
+			select {
+			case stream.incoming <- f.payload:
+			case <-stream.closed:
+			}
+		case flagClose:
+			stream.closeLocal()
+		}
+	}
+}
+
+func (t *TunnelConn) writeFrame(f frame) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return writeFrame(t.conn, f)
+}
+
+// OpenStream starts a new multiplexed stream over the tunnel and returns it
+// as an io.ReadWriteCloser the caller can write an HTTP request to and read
+// its response from.
+func (t *TunnelConn) OpenStream() (*tunnelStream, error) {
+	t.streamsMu.Lock()
+	t.nextID++
+	stream := newTunnelStream(t.nextID, t)
+	t.streams[stream.id] = stream
+	t.streamsMu.Unlock()
+
+	if err := t.writeFrame(frame{streamID: stream.id, flag: flagOpen}); err != nil {
+		t.removeStream(stream.id)
+		return nil, fmt.Errorf("opening tunnel stream: %w", err)
+	}
+	return stream, nil
+}
+
+func (t *TunnelConn) removeStream(id uint32) {
+	t.streamsMu.Lock()
+	delete(t.streams, id)
+	t.streamsMu.Unlock()
+}
+
+// Close tears down the tunnel and every stream still multiplexed over it.
+// Safe to call more than once.
+func (t *TunnelConn) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closed)
+
+		t.streamsMu.Lock()
+		streams := t.streams
+		t.streams = make(map[uint32]*tunnelStream)
+		t.streamsMu.Unlock()
+		for _, stream := range streams {
+			stream.closeLocal()
+		}
+
+		err = t.conn.Close()
+	})
+	return err
+}
+
+// RoundTrip implements http.RoundTripper by opening a fresh stream, writing
+// req to it, and parsing the response that comes back once the registering
+// client dials its local service and relays the reply. This lets
+// ProxyManager treat a tunneled node exactly like a directly reachable one.
+func (t *TunnelConn) RoundTrip(req *http.Request) (*http.Response, error) {
+	stream, err := t.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(stream); err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("writing request over tunnel: %w", err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(stream), req)
+	if err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("reading response over tunnel: %w", err)
+	}
+	return resp, nil
+}
+
+// TunnelManager tracks the active reverse tunnel for each tunnel-registered
+// node, so ProxyManager's transport can look one up by name-version-nodeID
+// and route traffic to it instead of dialing the node's Endpoint.
+type TunnelManager struct {
+	mu      sync.Mutex
+	tunnels map[string]*TunnelConn // Keyed by checkKey(name, version, nodeID).
+}
+
+// NewTunnelManager creates an empty TunnelManager.
+func NewTunnelManager() *TunnelManager {
+	return &TunnelManager{tunnels: make(map[string]*TunnelConn)}
+}
+
+// Register associates conn with the given node, closing and replacing
+// any previous tunnel for it (e.g. after the client reconnects).
+func (tm *TunnelManager) Register(name, version, nodeID string, conn *TunnelConn) {
+	key := checkKey(name, version, nodeID)
+
+	tm.mu.Lock()
+	old, exists := tm.tunnels[key]
+	tm.tunnels[key] = conn
+	tm.mu.Unlock()
+
+	if exists {
+		old.Close()
+	}
+}
+
+// Unregister removes and closes the tunnel for the given node, if any.
+func (tm *TunnelManager) Unregister(name, version, nodeID string) {
+	key := checkKey(name, version, nodeID)
+
+	tm.mu.Lock()
+	conn, exists := tm.tunnels[key]
+	delete(tm.tunnels, key)
+	tm.mu.Unlock()
+
+	if exists {
+		conn.Close()
+	}
+}
+
+// Get returns the active tunnel for the given node, if one is registered.
+func (tm *TunnelManager) Get(name, version, nodeID string) (*TunnelConn, bool) {
+	key := checkKey(name, version, nodeID)
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	conn, ok := tm.tunnels[key]
+	return conn, ok
+}
+
+// tunnelTransport is an http.RoundTripper that routes a proxied request to
+// the tunnel registered for its selected node, falling back to direct
+// dialing for nodes that registered a normal, reachable Endpoint.
+type tunnelTransport struct {
+	tunnels *TunnelManager
+	direct  http.RoundTripper
+}
+
+func (t *tunnelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	node, ok := req.Context().Value(selectedNodeKey{}).(*ServiceInfo)
+	if !ok {
+		return t.direct.RoundTrip(req)
+	}
+
+	tunnel, ok := t.tunnels.Get(node.Name, node.Version, node.NodeID)
+	if !ok {
+		return t.direct.RoundTrip(req)
+	}
+	return tunnel.RoundTrip(req)
+}
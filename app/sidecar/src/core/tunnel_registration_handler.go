@@ -0,0 +1,158 @@
+// core/tunnel_registration_handler.go
+// This file defines the HTTP handler for reverse-tunnel registration: the
+// variant of RegistrationHandler for nodes that have no reachable Endpoint
+// (e.g. behind NAT or a firewall). Instead of trusting an advertised
+// address, it hijacks the registering connection into a TunnelConn (see
+// tunnel.go) and registers the node against a synthetic tunnel:// endpoint
+// that ProxyManager's transport recognizes and routes traffic to over the
+// tunnel rather than dialing directly.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// TunnelRegistrationRequest is the JSON payload sent once, before the
+// connection is hijacked into a raw frame-multiplexed tunnel. Unlike
+// RegistrationRequest, it carries no Endpoint: the whole point of tunnel
+// registration is that the node doesn't have one reachable.
+type TunnelRegistrationRequest struct {
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	Metadata   map[string]string `json:"metadata"`
+	NodeID     string            `json:"node_id,omitempty"`     // Identifies this instance; omit to use the synthetic tunnel endpoint, which is the same for every node of the service, so a second node without one replaces the first
+	TTLSeconds int               `json:"ttl_seconds,omitempty"` // Lease TTL; omit or 0 means no expiry
+	Weight     int               `json:"weight,omitempty"`      // Relative share of traffic under the Weighted strategy; omit or 0 means 1
+	Middleware MiddlewareSpec    `json:"middleware,omitempty"`  // Chain installed in front of this service's shared proxy; only honored by the first node to register it
+}
+
+// TunnelRegistrationHandler handles POST /register/tunnel: it registers the
+// node, starts its shared proxy exactly as RegistrationHandler does, then
+// hijacks the connection and hands it to TunnelManager so ProxyManager can
+// multiplex proxied requests over it.
+type TunnelRegistrationHandler struct {
+	registry     RegistryStore
+	proxyManager *ProxyManager
+	tunnels      *TunnelManager
+}
+
+// NewTunnelRegistrationHandler creates a new TunnelRegistrationHandler.
+func NewTunnelRegistrationHandler(registry RegistryStore, proxyManager *ProxyManager, tunnels *TunnelManager) *TunnelRegistrationHandler {
+	return &TunnelRegistrationHandler{
+		registry:     registry,
+		proxyManager: proxyManager,
+		tunnels:      tunnels,
+	}
+}
+
+// tunnelEndpoint builds the synthetic Endpoint stored against a
+// tunnel-registered node. It's never dialed; it only has to be a valid URL
+// so ProxyManager's director can parse it like any other node's Endpoint.
+func tunnelEndpoint(name, version string) string {
+	return fmt.Sprintf("tunnel://%s", serviceKey(name, version))
+}
+
+// ServeHTTP implements the http.Handler interface for the tunnel
+// registration endpoint.
+func (h *TunnelRegistrationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TunnelRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" || req.Version == "" {
+		http.Error(w, "Missing required fields (name, version)", http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Server does not support the hijacking required for tunnel registration", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Received tunnel registration request for service '%s' (%s)", req.Name, req.Version)
+
+	opts := RegisterOptions{Weight: req.Weight}
+	if req.TTLSeconds > 0 {
+		opts.TTL = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	serviceInfo, err := h.registry.Register(req.Name, req.Version, tunnelEndpoint(req.Name, req.Version), req.Metadata, req.NodeID, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to register service: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	proxyPort, err := h.proxyManager.StartProxy(serviceInfo, req.Middleware)
+	if err != nil {
+		h.registry.Deregister(req.Name, req.Version, serviceInfo.NodeID)
+		http.Error(w, fmt.Sprintf("Failed to start proxy for service '%s': %v", req.Name, err), http.StatusInternalServerError)
+		return
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		h.registry.Deregister(req.Name, req.Version, serviceInfo.NodeID)
+		http.Error(w, fmt.Sprintf("Failed to hijack connection: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp := RegistrationResponse{
+		Message:   fmt.Sprintf("Service '%s' (%s) node '%s' registered over tunnel; proxy listening on port %d.", req.Name, req.Version, serviceInfo.NodeID, proxyPort),
+		ProxyPort: proxyPort,
+		NodeID:    serviceInfo.NodeID,
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Failed to encode tunnel registration response: %v", err)
+		conn.Close()
+		return
+	}
+	if _, err := fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		log.Printf("Failed to write tunnel registration response: %v", err)
+		conn.Close()
+		return
+	}
+	if err := buf.Flush(); err != nil {
+		log.Printf("Failed to flush tunnel registration response: %v", err)
+		conn.Close()
+		return
+	}
+
+	tunnel := newTunnelConn(conn)
+	h.tunnels.Register(req.Name, req.Version, serviceInfo.NodeID, tunnel)
+	log.Printf("Service '%s' (%s) node '%s' opened a reverse tunnel; proxy listening on port %d.", req.Name, req.Version, serviceInfo.NodeID, proxyPort)
+
+	go h.awaitDisconnect(req.Name, req.Version, serviceInfo.NodeID, tunnel)
+}
+
+// awaitDisconnect deregisters a tunnel node once its connection closes, and
+// stops its service's proxy if it was the last node left.
+func (h *TunnelRegistrationHandler) awaitDisconnect(name, version, nodeID string, tunnel *TunnelConn) {
+	<-tunnel.closed
+	h.tunnels.Unregister(name, version, nodeID)
+
+	log.Printf("Tunnel for node '%s' of service '%s-%s' closed; deregistering.", nodeID, name, version)
+	if err := h.registry.Deregister(name, version, nodeID); err != nil {
+		log.Printf("Failed to deregister tunnel node '%s': %v", nodeID, err)
+	}
+
+	if _, stillRegistered := h.registry.Get(name, version); stillRegistered {
+		return
+	}
+	if err := h.proxyManager.StopProxy(name, version); err != nil {
+		log.Printf("Failed to stop proxy for '%s-%s' after its tunnel closed: %v", name, version, err)
+	}
+}
@@ -1,12 +1,18 @@
 // main.go
-// This is the entry point of the Icaht Sidecar application.
-// It initializes the necessary components, loads the configuration,
-// and starts the main processes, including the API server for
-// service registration and the core proxy management.
+// This is the entry point of the Icaht Sidecar application. It dispatches
+// on a subcommand the same way client/env's CLI dispatches on
+// "github-actions": `serve` (the default if no subcommand is given) runs
+// the sidecar itself; `list`, `stop`, and `reload` are thin HTTP clients an
+// operator runs against an already-running sidecar's registration and
+// diagnostics APIs.
 
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -19,32 +25,146 @@ import (
 )
 
 func main() {
-	// Load the application configuration.
-	// This reads settings from environment variables or configuration files.
-	cfg, err := configuration.Load()
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			runServe(args[1:])
+			return
+		case "list":
+			runList(args[1:])
+			return
+		case "stop":
+			runStop(args[1:])
+			return
+		case "reload":
+			runReload(args[1:])
+			return
+		}
+	}
+	runServe(args)
+}
+
+// runServe starts the sidecar: the registration/heartbeat/discovery API,
+// the proxy manager, the health checker, and the diagnostics listener, and
+// blocks until a shutdown signal arrives. This is what main did before the
+// `list`/`stop`/`reload` subcommands existed, so bare invocation with no
+// subcommand still works.
+func runServe(args []string) {
+	// Load the application configuration: built-in defaults, an optional
+	// -config YAML file, ICAHT_-prefixed environment variables, then CLI
+	// flags, each overriding the last.
+	cfg, err := configuration.Load(args)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 	log.Printf("Configuration loaded successfully: %+v", cfg)
+	core.SetLogLevel(cfg.LogLevel)
+
+	// cfgManager makes cfg reloadable: a SIGHUP, or a POST /reload from the
+	// `reload` subcommand, re-runs the same layered Load and, for fields
+	// that support it, re-applies the new value to the already-running
+	// components below via the hooks registered after they're constructed.
+	cfgManager := configuration.NewManager(cfg, args)
+	go cfgManager.WatchSIGHUP(context.Background())
 
-	// Initialize the service registry.
-	// This component will store information about registered microservices.
-	registry := core.NewRegistry()
+	// Initialize the service registry. MemoryRegistry is the default,
+	// in-process RegistryStore; operators running a multi-node control
+	// plane can swap in core.NewEtcdRegistry, core.NewConsulRegistry or
+	// core.NewRedisRegistry instead once cfg exposes that choice.
+	registry := core.NewMemoryRegistry()
 	log.Println("Service registry initialized.")
 
+	// Initialize the tunnel manager. It tracks reverse tunnels opened by
+	// nodes with no reachable Endpoint (behind NAT or a firewall), so the
+	// proxy manager can route traffic to them instead of dialing directly.
+	tunnelManager := core.NewTunnelManager()
+	log.Println("Tunnel manager initialized.")
+
 	// Initialize the proxy manager.
 	// This component is responsible for creating and managing the lifecycle
 	// of the individual service proxies. It needs a reference to the registry
 	// to know which services to proxy.
-	proxyManager := core.NewProxyManager(registry, cfg.ProxyPortRangeStart)
+	proxyManager := core.NewProxyManager(registry, cfg.ProxyPortRangeStart, core.WithTunnelManager(tunnelManager), core.WithShutdownTimeout(cfg.ShutdownTimeout))
 	log.Println("Proxy manager initialized.")
 
-	// Start the API server for service registration.
+	// Wire the fields a config reload can change without a restart to the
+	// components they configure.
+	// Initialize the active health checker. Services that registered with a
+	// health_check are probed on their declared interval (cfg.DefaultHealthCheckInterval
+	// when they omit one); enough consecutive failures deregisters the node
+	// and, once it was the last one, stops the service's proxy.
+	healthChecker := core.NewHealthChecker(registry, proxyManager, cfg.DefaultHealthCheckInterval)
+	log.Println("Health checker initialized.")
+
+	cfgManager.OnReload(func(previous, next configuration.Config) {
+		if next.ProxyPortRangeStart != previous.ProxyPortRangeStart {
+			proxyManager.SetPortRangeStart(next.ProxyPortRangeStart)
+		}
+		if next.ShutdownTimeout != previous.ShutdownTimeout {
+			proxyManager.SetShutdownTimeout(next.ShutdownTimeout)
+		}
+		if next.DefaultHealthCheckInterval != previous.DefaultHealthCheckInterval {
+			healthChecker.SetDefaultInterval(next.DefaultHealthCheckInterval)
+		}
+		if next.LogLevel != previous.LogLevel {
+			core.SetLogLevel(next.LogLevel)
+		}
+	})
+
+	// Probe whatever's been pushed to the proxy manager via UpdateEndpoints
+	// (an xDS-style config push, bypassing registration entirely). Probing
+	// only runs when core.WithEndpointHealthCheck is passed to
+	// NewProxyManager; it isn't passed above, so this returns a no-op stop
+	// function whether or not UpdateEndpoints is used.
+	stopEndpointChecks := proxyManager.StartEndpointHealthChecks()
+
+	// Periodically evict services that miss their heartbeat window, tearing
+	// down their proxy so the port can be reused.
+	stopExpiryScanner := registry.StartExpiryScanner(cfg.HeartbeatScanInterval, func(expired *core.ServiceInfo) {
+		if _, stillRegistered := registry.Get(expired.Name, expired.Version); stillRegistered {
+			return // Other nodes are still serving this service; keep its shared proxy running.
+		}
+		if err := proxyManager.StopProxy(expired.Name, expired.Version); err != nil {
+			log.Printf("Failed to stop proxy for expired service '%s-%s': %v", expired.Name, expired.Version, err)
+		}
+	})
+
+	// Re-establish proxies for whatever is still on record in the backing
+	// store (e.g. registrations that survived a restart in a persistent
+	// store like core.NewBoltRegistry) before the API starts accepting new
+	// registrations, so already-registered services aren't briefly
+	// unreachable.
+	if err := proxyManager.RestoreProxies(); err != nil {
+		log.Printf("Error restoring proxies from the registry: %v", err)
+	}
+
+	// Start the API server for service registration and heartbeats.
 	// This server listens for incoming HTTP requests from microservices
-	// that want to register themselves with the sidecar.
+	// that want to register themselves with the sidecar or renew their lease.
+	mux := http.NewServeMux()
+	mux.Handle("/register", core.NewRegistrationHandler(registry, proxyManager, healthChecker))
+	mux.Handle("/register/tunnel", core.NewTunnelRegistrationHandler(registry, proxyManager, tunnelManager))
+	mux.Handle("/heartbeat", core.NewHeartbeatHandler(registry))
+	mux.Handle("/services", core.NewDiscoveryHandler(registry))
+	mux.Handle("/services/", core.NewDiscoveryHandler(registry))
+	mux.Handle("/stop", core.NewStopHandler(registry, proxyManager))
+	mux.Handle("/debug/config", cfgManager.DebugHandler())
+	mux.HandleFunc("/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := cfgManager.Reload(); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to reload configuration: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
 	registrationServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.RegistrationPort),
-		Handler: core.NewRegistrationHandler(registry, proxyManager), // Handler for registration requests
+		Handler: mux,
 	}
 
 	// Start the registration server in a goroutine so it doesn't block
@@ -56,6 +176,20 @@ func main() {
 		}
 	}()
 
+	// Start the diagnostics listener on its own port/bind-address, separate
+	// from the registration API, so it stays reachable for profiling and
+	// health probes even under registration-side load.
+	diagnosticsServer := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.DiagnosticsBindAddress, cfg.DiagnosticsPort),
+		Handler: core.NewDiagnosticsServer(proxyManager).Handler(),
+	}
+	go func() {
+		log.Printf("Starting diagnostics server on %s:%d", cfg.DiagnosticsBindAddress, cfg.DiagnosticsPort)
+		if err := diagnosticsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Diagnostics server stopped unexpectedly: %v", err)
+		}
+	}()
+
 	// Set up signal handling for graceful shutdown.
 	// This listens for OS signals like SIGINT (Ctrl+C) and SIGTERM (Docker stop).
 	quit := make(chan os.Signal, 1)
@@ -65,20 +199,144 @@ func main() {
 	<-quit
 	log.Println("Received shutdown signal. Shutting down...")
 
+	// The registration server, the diagnostics server and the proxies share
+	// one drain deadline and are shut down one after another, so time spent
+	// by an earlier step is no longer available to the later ones.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
 	// Perform graceful shutdown of the registration server.
 	// This gives the server a chance to finish any in-flight requests
 	// before exiting.
-	if err := registrationServer.Shutdown(nil); err != nil {
+	if err := registrationServer.Shutdown(shutdownCtx); err != nil {
 		log.Fatalf("Registration server shutdown failed: %v", err)
 	}
 	log.Println("Service registration API server stopped.")
 
+	if err := diagnosticsServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Diagnostics server shutdown failed: %v", err)
+	}
+	log.Println("Diagnostics server stopped.")
+
 	// Perform any other cleanup operations here, like stopping the
-	// proxy manager and any other background processes.
-	if err := proxyManager.ShutdownAll(); err != nil {
+	// proxy manager and any other background processes. This actually
+	// drains and closes every proxied listener now that ProxyManager keeps
+	// a handle to each service's *http.Server.
+	if err := proxyManager.ShutdownAll(shutdownCtx); err != nil {
 		log.Printf("Proxy manager shutdown encountered errors: %v", err)
 	}
 	log.Println("Proxy manager stopped.")
 
+	stopExpiryScanner()
+	stopEndpointChecks()
+	healthChecker.StopAll()
+
 	log.Println("Icaht Sidecar stopped gracefully.")
-}
\ No newline at end of file
+}
+
+// fetchJSON GETs url and decodes its JSON body into a T, for the read-only
+// subcommands below.
+func fetchJSON[T any](url string) (T, error) {
+	var result T
+	resp, err := http.Get(url)
+	if err != nil {
+		return result, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("request to %s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("decoding response from %s: %w", url, err)
+	}
+	return result, nil
+}
+
+// runList implements the `list` subcommand: it fetches every registered
+// service from a running sidecar's discovery API, and each service's proxy
+// status from its diagnostics API, and prints a combined summary.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	apiURL := fs.String("api-url", "http://localhost:8500", "Base URL of the sidecar's registration/discovery API")
+	diagnosticsURL := fs.String("diagnostics-url", "http://localhost:8501", "Base URL of the sidecar's diagnostics API")
+	fs.Parse(args)
+
+	services, err := fetchJSON[map[string][]*core.ServiceInfo](*apiURL + "/services")
+	if err != nil {
+		log.Fatalf("Failed to list services: %v", err)
+	}
+	proxies, err := fetchJSON[[]core.ProxyStatus](*diagnosticsURL + "/v1/proxies")
+	if err != nil {
+		log.Fatalf("Failed to list proxies: %v", err)
+	}
+
+	proxyByKey := make(map[string]core.ProxyStatus, len(proxies))
+	for _, proxy := range proxies {
+		proxyByKey[proxy.Key] = proxy
+	}
+
+	if len(services) == 0 {
+		fmt.Println("No services registered.")
+		return
+	}
+
+	for key, nodes := range services {
+		proxy := proxyByKey[key]
+		fmt.Printf("%s: %d node(s), proxy port %d, uptime %s, %d requests served\n", key, len(nodes), proxy.Port, proxy.Uptime, proxy.Requests)
+		for _, node := range nodes {
+			fmt.Printf("  - %s %s (health=%s, weight=%d)\n", node.NodeID, node.Endpoint, node.Health(), node.EffectiveWeight())
+		}
+	}
+}
+
+// runStop implements the `stop <name> <version>` subcommand: it tears down
+// every node of a service and its shared proxy via a running sidecar's
+// /stop endpoint.
+func runStop(args []string) {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	apiURL := fs.String("api-url", "http://localhost:8500", "Base URL of the sidecar's registration API")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		log.Fatal("Usage: stop [--api-url <url>] <name> <version>")
+	}
+	name, version := fs.Arg(0), fs.Arg(1)
+
+	body, err := json.Marshal(core.StopRequest{Name: name, Version: version})
+	if err != nil {
+		log.Fatalf("Failed to encode stop request: %v", err)
+	}
+
+	resp, err := http.Post(*apiURL+"/stop", "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Fatalf("Failed to stop service '%s-%s': %v", name, version, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		log.Fatalf("Failed to stop service '%s-%s': server returned %s", name, version, resp.Status)
+	}
+
+	fmt.Printf("Stopped service '%s-%s'.\n", name, version)
+}
+
+// runReload implements the `reload` subcommand: it triggers a config
+// reload on a running sidecar via its /reload endpoint, equivalent to
+// sending it SIGHUP but usable from environments (containers without a
+// shared PID namespace, for instance) where sending a signal isn't
+// convenient.
+func runReload(args []string) {
+	fs := flag.NewFlagSet("reload", flag.ExitOnError)
+	apiURL := fs.String("api-url", "http://localhost:8500", "Base URL of the sidecar's registration API")
+	fs.Parse(args)
+
+	resp, err := http.Post(*apiURL+"/reload", "application/json", nil)
+	if err != nil {
+		log.Fatalf("Failed to trigger reload: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		log.Fatalf("Failed to trigger reload: server returned %s", resp.Status)
+	}
+
+	fmt.Println("Configuration reloaded.")
+}
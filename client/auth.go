@@ -0,0 +1,341 @@
+package rest_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider applies authentication to an outgoing request. It supersedes
+// the plain apiKey/apiKeyHeaderName fields, letting Client support schemes
+// beyond a single static header (OAuth2 token refresh, Vault lease renewal,
+// etc.) behind one interface.
+type AuthProvider interface {
+	// Apply mutates req to add whatever credentials the provider manages
+	// (typically an Authorization header). It is called after default
+	// headers are applied, so it can override them.
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// Reauthenticator is an optional interface an AuthProvider can implement to
+// discard any cached credential. When set, Do calls it once after a 401
+// response and retries the request a single additional time with a freshly
+// fetched credential.
+type Reauthenticator interface {
+	Reauthenticate()
+}
+
+// WithAuth sets the AuthProvider used to authenticate every request made by
+// the client. It composes with WithAPIKey: if both are set, WithAuth's
+// provider runs last and wins.
+//
+// Usage example:
+//
+//	client := rest_client.NewClient(
+//	    rest_client.WithBaseURL("https://api.example.com/v1"),
+//	    rest_client.WithAuth(rest_client.NewStaticTokenAuth("sk_1234", "Authorization")),
+//	)
+//
+// Parameters:
+//   - provider: the AuthProvider to invoke on every request.
+//
+// Returns:
+//   - ClientOption: a function to set the auth provider on the client.
+func WithAuth(provider AuthProvider) ClientOption {
+	return func(c *Client) {
+		c.auth = provider
+	}
+}
+
+// StaticTokenAuth attaches a fixed bearer-style token to a header on every
+// request. It is the AuthProvider equivalent of WithAPIKey/WithAPIKeyHeaderName.
+type StaticTokenAuth struct {
+	Token      string
+	HeaderName string // defaults to "Authorization"
+	Scheme     string // prefix before the token, e.g. "Bearer"; empty means no prefix
+}
+
+// NewStaticTokenAuth returns a StaticTokenAuth that sets "Bearer <token>" on
+// the given header (or "Authorization" if headerName is empty).
+func NewStaticTokenAuth(token, headerName string) *StaticTokenAuth {
+	if headerName == "" {
+		headerName = "Authorization"
+	}
+	return &StaticTokenAuth{Token: token, HeaderName: headerName, Scheme: "Bearer"}
+}
+
+// Apply implements AuthProvider.
+func (a *StaticTokenAuth) Apply(_ context.Context, req *http.Request) error {
+	value := a.Token
+	if a.Scheme != "" {
+		value = a.Scheme + " " + value
+	}
+	headerName := a.HeaderName
+	if headerName == "" {
+		headerName = "Authorization"
+	}
+	req.Header.Set(headerName, value)
+	return nil
+}
+
+// BasicAuth applies HTTP Basic authentication (RFC 7617).
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Apply implements AuthProvider.
+func (a *BasicAuth) Apply(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// TokenFetcher retrieves a fresh OAuth2-style access token, typically by
+// calling a token endpoint. It is the extension point OAuth2ClientCredentialsAuth
+// and VaultAuth use to pull new credentials once the cached ones expire.
+type TokenFetcher interface {
+	FetchToken(ctx context.Context) (token string, expiresAt time.Time, err error)
+}
+
+// cachedToken is the mutex-guarded refresh cache shared by OAuth2ClientCredentialsAuth
+// and VaultAuth.
+type cachedToken struct {
+	mu        sync.Mutex
+	value     string
+	expiresAt time.Time
+}
+
+// tokenRefreshSkew is how long before its expiry cachedToken.get treats a
+// token as stale and fetches a new one, so a request never races an
+// about-to-expire token.
+const tokenRefreshSkew = 30 * time.Second
+
+func (c *cachedToken) get(ctx context.Context, fetch func(context.Context) (string, time.Time, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.value != "" && time.Now().Add(tokenRefreshSkew).Before(c.expiresAt) {
+		return c.value, nil
+	}
+
+	token, expiresAt, err := fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch token: %w", err)
+	}
+	c.value = token
+	c.expiresAt = expiresAt
+	return token, nil
+}
+
+// invalidate clears the cached token, forcing the next get to fetch a fresh
+// one. Used by the 401 re-auth-and-retry hook.
+func (c *cachedToken) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = ""
+	c.expiresAt = time.Time{}
+}
+
+// OAuth2ClientCredentialsAuth implements the OAuth2 client-credentials grant
+// (RFC 6749 section 4.4): it POSTs client_id/client_secret to TokenURL,
+// caches the returned access_token, and refreshes it shortly before expiry.
+type OAuth2ClientCredentialsAuth struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	HTTPClient   *http.Client // defaults to http.DefaultClient
+
+	cache cachedToken
+}
+
+// Apply implements AuthProvider, fetching/refreshing the token as needed and
+// setting it as a Bearer token.
+func (a *OAuth2ClientCredentialsAuth) Apply(ctx context.Context, req *http.Request) error {
+	token, err := a.cache.get(ctx, a.FetchToken)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Reauthenticate implements the Reauthenticator hook Do uses to force a
+// single re-auth + retry after a 401 response.
+func (a *OAuth2ClientCredentialsAuth) Reauthenticate() {
+	a.cache.invalidate()
+}
+
+// FetchToken implements TokenFetcher by performing the client-credentials
+// token request. Exposed separately so tests and VaultAuth-style wrappers
+// can call it directly without going through the cache.
+func (a *OAuth2ClientCredentialsAuth) FetchToken(ctx context.Context) (string, time.Time, error) {
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	oauthClient := NewClient(
+		WithBaseURL(a.TokenURL),
+		WithHeader("Content-Type", "application/x-www-form-urlencoded"),
+	)
+	oauthClient.httpClient = httpClient
+
+	values := url.Values{}
+	values.Set("grant_type", "client_credentials")
+	values.Set("client_id", a.ClientID)
+	values.Set("client_secret", a.ClientSecret)
+	if len(a.Scopes) > 0 {
+		values.Set("scope", strings.Join(a.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.TokenURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := oauthClient.doRawRequest(req)
+	if err != nil && resp == nil {
+		return "", time.Time{}, fmt.Errorf("failed to request token: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return "", time.Time{}, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := resp.Decode(&token); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token endpoint returned no access_token")
+	}
+
+	expiresIn := time.Duration(token.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 5 * time.Minute
+	}
+
+	return token.AccessToken, time.Now().Add(expiresIn), nil
+}
+
+// rawJSON is a json.Marshaler whose MarshalJSON returns the string's bytes
+// unchanged; json.Marshal still rejects them unless they are valid JSON.
+type rawJSON string
+
+// MarshalJSON implements json.Marshaler, returning r's bytes as-is rather
+// than a JSON string literal.
+func (r rawJSON) MarshalJSON() ([]byte, error) {
+	return []byte(r), nil
+}
+
+// VaultAuth reads a secret (expected to carry its token in auth.client_token,
+// Vault's auth response envelope) from a Vault-style
+// endpoint and renews its lease via a background goroutine, similar to
+// Vault's api.Renewer.
+type VaultAuth struct {
+	Address    string // Vault address, e.g. "https://vault.internal:8200"
+	SecretPath string // path to read, e.g. "auth/approle/login"
+	Token      string // Vault token used to authenticate the read itself
+	LeaseTTL   time.Duration
+
+	HTTPClient *http.Client
+
+	cache      cachedToken
+	renewOnce  sync.Once
+	stopRenew  chan struct{}
+}
+
+// Apply implements AuthProvider, using the cached lease token as a Bearer
+// credential and starting the background renewer on first use.
+func (a *VaultAuth) Apply(ctx context.Context, req *http.Request) error {
+	a.renewOnce.Do(func() {
+		a.stopRenew = make(chan struct{})
+		go a.renewLoop()
+	})
+
+	token, err := a.cache.get(ctx, a.FetchToken)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Reauthenticate implements the Reauthenticator hook Do uses to force a
+// single re-auth + retry after a 401 response.
+func (a *VaultAuth) Reauthenticate() {
+	a.cache.invalidate()
+}
+
+// FetchToken implements TokenFetcher by reading SecretPath from the Vault
+// address and returning its lease token and expiry.
+func (a *VaultAuth) FetchToken(ctx context.Context) (string, time.Time, error) {
+	vaultClient := NewClient(
+		WithBaseURL(a.Address),
+		WithHeader("X-Vault-Token", a.Token),
+	)
+	if a.HTTPClient != nil {
+		vaultClient.httpClient = a.HTTPClient
+	}
+
+	resp, err := vaultClient.Get(ctx, "/v1/"+a.SecretPath, nil, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return "", time.Time{}, fmt.Errorf("vault returned %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var secret struct {
+		LeaseDuration int `json:"lease_duration"`
+		Auth          struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := resp.Decode(&secret); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode vault secret: %w", err)
+	}
+
+	ttl := a.LeaseTTL
+	if secret.Auth.LeaseDuration > 0 {
+		ttl = time.Duration(secret.Auth.LeaseDuration) * time.Second
+	} else if secret.LeaseDuration > 0 {
+		ttl = time.Duration(secret.LeaseDuration) * time.Second
+	}
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+
+	return secret.Auth.ClientToken, time.Now().Add(ttl), nil
+}
+
+// renewLoop periodically re-fetches the lease ahead of its expiry, mirroring
+// Vault's Renewer goroutine, until Stop is called.
+func (a *VaultAuth) renewLoop() {
+	ticker := time.NewTicker(tokenRefreshSkew * 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stopRenew:
+			return
+		case <-ticker.C:
+			a.cache.invalidate()
+			_, _ = a.cache.get(context.Background(), a.FetchToken)
+		}
+	}
+}
+
+// Stop terminates the background lease-renewal goroutine.
+func (a *VaultAuth) Stop() {
+	if a.stopRenew != nil {
+		close(a.stopRenew)
+	}
+}
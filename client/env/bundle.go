@@ -0,0 +1,217 @@
+// bundle.go
+// EncryptedBundle is the env-client CLI's cryptographic upload format: the
+// local .env is encrypted to one or more age recipients before
+// UploadObfuscatedEnvFile ever sees it, and decrypted with the caller's
+// identity after DownloadFromLink, so the server handling the "obfuscated"
+// payload never sees plaintext. Enabled by passing --recipients; without
+// it the CLI uploads the source file as-is, same as before this existed.
+// Requires filippo.io/age as a dependency.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// encryptedBundleSentinel marks a payload as an EncryptedBundle (JSON) so
+// the CLI can tell it apart from a legacy plaintext obfuscated .env on
+// download.
+const encryptedBundleSentinel = "dfbundle:v1:"
+
+// EncryptedBundle is the JSON shape uploaded/downloaded as the "obfuscated"
+// payload when --recipients is set: the age ciphertext plus the recipient
+// public keys it was sealed to, so --rotate knows who to re-encrypt for
+// without the caller repeating --recipients.
+type EncryptedBundle struct {
+	Ciphertext string   `json:"ciphertext"` // base64-encoded age ciphertext
+	Recipients []string `json:"recipients"` // age public keys (age1...) the bundle is encrypted to
+}
+
+// loadOrGenerateIdentity reads an age X25519 identity from path, generating
+// and saving a new one there if it doesn't exist yet. An empty path
+// generates an ephemeral identity that's never saved — fine for a quick
+// test, but anything encrypted to it is unrecoverable once the process
+// exits.
+func loadOrGenerateIdentity(path string) (*age.X25519Identity, error) {
+	if path == "" {
+		return age.GenerateX25519Identity()
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		identity, err := age.ParseX25519Identity(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("parsing identity file %q: %w", path, err)
+		}
+		return identity, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading identity file %q: %w", path, err)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, fmt.Errorf("generating identity: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(identity.String()+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("saving identity file %q: %w", path, err)
+	}
+	fmt.Printf("Generated a new age identity and saved it to %s. Back this file up: anything encrypted to its public key (%s) is unrecoverable without it.\n", path, identity.Recipient())
+	return identity, nil
+}
+
+// splitRecipientKeys splits a comma-separated --recipients value into its
+// trimmed, non-empty entries.
+func splitRecipientKeys(csv string) []string {
+	var keys []string
+	for _, part := range strings.Split(csv, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			keys = append(keys, part)
+		}
+	}
+	return keys
+}
+
+// parseRecipients parses age public keys into the age.Recipient values
+// age.Encrypt needs.
+func parseRecipients(keys []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("parsing recipient %q: %w", key, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients given")
+	}
+	return recipients, nil
+}
+
+// encryptBundle seals plaintext to recipients. recipientKeys is stored
+// alongside the ciphertext so a later --rotate doesn't need --recipients
+// repeated verbatim.
+func encryptBundle(plaintext []byte, recipientKeys []string, recipients []age.Recipient) (*EncryptedBundle, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("age: failed to start encryption: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("age: failed to encrypt: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("age: failed to finalize encryption: %w", err)
+	}
+
+	return &EncryptedBundle{
+		Ciphertext: base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Recipients: recipientKeys,
+	}, nil
+}
+
+// decryptBundle opens an EncryptedBundle with identity.
+func decryptBundle(bundle *EncryptedBundle, identity age.Identity) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(bundle.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decoding bundle ciphertext: %w", err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identity)
+	if err != nil {
+		return nil, fmt.Errorf("age: failed to decrypt: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("age: failed to read decrypted content: %w", err)
+	}
+	return plaintext, nil
+}
+
+// marshalBundle wraps bundle in encryptedBundleSentinel + JSON, the string
+// form stored/uploaded as the "obfuscated" payload.
+func marshalBundle(bundle *EncryptedBundle) (string, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return "", fmt.Errorf("encoding bundle: %w", err)
+	}
+	return encryptedBundleSentinel + string(data), nil
+}
+
+// unmarshalBundle reverses marshalBundle. ok is false if content doesn't
+// carry encryptedBundleSentinel, meaning it's a legacy plaintext obfuscated
+// .env rather than an EncryptedBundle.
+func unmarshalBundle(content string) (bundle *EncryptedBundle, ok bool, err error) {
+	if !strings.HasPrefix(content, encryptedBundleSentinel) {
+		return nil, false, nil
+	}
+	bundle = &EncryptedBundle{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(content, encryptedBundleSentinel)), bundle); err != nil {
+		return nil, true, fmt.Errorf("decoding bundle: %w", err)
+	}
+	return bundle, true, nil
+}
+
+// runRotateBundle implements --rotate: it reads the EncryptedBundle at
+// path, decrypts it with the identity at identityPath, re-encrypts it to
+// the recipients in recipientsCSV, and writes the result back in place.
+func runRotateBundle(path, identityPath, recipientsCSV string) {
+	identity, err := loadOrGenerateIdentity(identityPath)
+	if err != nil {
+		fatalExit(5, "Failed to load identity: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalExit(3, "Failed to read bundle file %q: %v", path, err)
+	}
+
+	bundle, ok, err := unmarshalBundle(string(content))
+	if err != nil {
+		fatalExit(4, "Failed to parse bundle: %v", err)
+	}
+	if !ok {
+		fatalExit(1, "%q is not an EncryptedBundle; nothing to rotate", path)
+	}
+
+	plaintext, err := decryptBundle(bundle, identity)
+	if err != nil {
+		fatalExit(4, "Failed to decrypt bundle: %v", err)
+	}
+
+	recipientKeys := splitRecipientKeys(recipientsCSV)
+	recipients, err := parseRecipients(recipientKeys)
+	if err != nil {
+		fatalExit(1, "Failed to parse --recipients: %v", err)
+	}
+
+	rotated, err := encryptBundle(plaintext, recipientKeys, recipients)
+	if err != nil {
+		fatalExit(4, "Failed to re-encrypt bundle: %v", err)
+	}
+	serialized, err := marshalBundle(rotated)
+	if err != nil {
+		fatalExit(4, "Failed to encode rotated bundle: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(serialized), 0600); err != nil {
+		fatalExit(3, "Failed to write rotated bundle to %q: %v", path, err)
+	}
+	fmt.Printf("Rotated %s to %d recipient(s).\n", path, len(recipients))
+}
+
+// fatalExit prints an error message to stderr and exits with code, for the
+// exit codes (4, 5) that log.Fatal's hardcoded 1 can't express.
+func fatalExit(code int, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(code)
+}
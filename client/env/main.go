@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	env_client "dataforge/manager/client/env"
 	"github.com/joho/godotenv" // For loading env vars to system environment
 )
 
@@ -71,6 +72,29 @@ import (
 // - `1`: Invalid usage or missing required flags
 // - `2`: API request failed
 // - `3`: File IO error
+// - `4`: Encryption/decryption error (see --recipients/--rotate below)
+// - `5`: Identity error (unreadable or invalid age identity file, or one that can't be saved; a missing file is generated)
+//
+// ## 🔐 EncryptedBundle mode (--recipients / --identity / --rotate)
+// By default the "obfuscated" payload this tool uploads/downloads carries
+// no real cryptography — it's whatever the caller already obfuscated it
+// into. Passing `--recipients` switches to an EncryptedBundle: the local
+// .env at `--source` is encrypted client-side with age
+// (filippo.io/age) to every comma-separated recipient public key before
+// upload, and decrypted with the identity at `--identity` after download,
+// so the server only ever stores/serves ciphertext.
+// ```bash
+// ./env-client --env-id env_abc123 --source ./.env --identity ./age.key \
+//   --recipients age1qyqs...,age1rg8e...
+// ```
+// If `--identity` doesn't exist yet, a new X25519 identity is generated and
+// saved there. `--rotate` re-encrypts the bundle at `--source` to a new
+// `--recipients` set (e.g. after a team member leaves) without contacting
+// the API:
+// ```bash
+// ./env-client --source ./.env.obfuscated --identity ./age.key --rotate \
+//   --recipients age1qyqs...
+// ```
 //
 // ## 🛠️ Example Automation in CI:
 // ```yaml
@@ -82,17 +106,61 @@ import (
 // ```
 //
 // ---
+//
+// ## ⚙️ github-actions subcommand
+// Exports an environment straight into the running job via GitHub Actions
+// workflow commands, instead of writing a local .env file:
+// ```bash
+// ./env-client github-actions --env-id env_abc123 --api-key your-api-key
+// ```
+// Masked variables are redacted from the log with `::add-mask::` before
+// their values are written to `$GITHUB_ENV`, `$GITHUB_OUTPUT`, and (for a
+// variable named `PATH`) `$GITHUB_PATH`.
+//
+// ---
+//
+// ## 🔀 sync subcommand
+// The default flow above is a one-way overwrite: whichever side runs it
+// last wins. `sync` instead does a three-way merge between --source
+// (ours), the remote environment (theirs), and a stored --base snapshot
+// of the last-synced common ancestor, so two people (or a person and CI)
+// can both edit the same environment without clobbering each other:
+// ```bash
+// ./env-client sync --env-id env_abc123 --source ./.env --base ./.env.base --strategy=prompt
+// ```
+// Conflicting keys (changed on both sides since --base) are resolved per
+// --strategy (ours, theirs, prompt, or fail); --dry-run prints the merge
+// diff without writing --source, --base, or the remote environment.
+//
+// ---
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "github-actions" {
+		runGitHubActionsExport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		runSync(os.Args[2:])
+		return
+	}
+
 	// Define command-line flags
 	apiBaseURL := flag.String("api-url", "https://api.envmanagement.com/v1", "Base URL for the environment API")
 	apiKey := flag.String("api-key", "", "API key for authentication")
 	envID := flag.String("env-id", "", "Environment ID to work with")
-	sourcePath := flag.String("source", "./.env.obfuscated", "Path to the obfuscated .env file")
+	sourcePath := flag.String("source", "./.env.obfuscated", "Path to the obfuscated .env file (or the plaintext .env to encrypt, with --recipients)")
 	destPath := flag.String("dest", "./.env", "Path where the downloaded .env file will be saved")
 	timeout := flag.Int("timeout", 30, "Timeout in seconds for API operations")
+	recipients := flag.String("recipients", "", "Comma-separated age public keys to encrypt the uploaded .env for; enables EncryptedBundle mode")
+	identityPath := flag.String("identity", "", "Path to an age X25519 identity file for decrypting EncryptedBundle payloads; generated there if missing")
+	rotate := flag.Bool("rotate", false, "Re-encrypt the EncryptedBundle at --source to the current --recipients set, then exit (no API call)")
 	flag.Parse()
 
+	if *rotate {
+		runRotateBundle(*sourcePath, *identityPath, *recipients)
+		return
+	}
+
 	// Validate required flags
 	if *apiKey == "" {
 		// Try to get from environment if not provided as flag
@@ -119,19 +187,38 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout+5)*time.Second)
 	defer cancel()
 
-	// Read the obfuscated .env file
-	fmt.Printf("Reading obfuscated .env file from %s...\n", *sourcePath)
+	// Read the source file: the obfuscated .env to upload as-is, or, with
+	// --recipients, the plaintext .env to encrypt before it's uploaded.
+	fmt.Printf("Reading source file from %s...\n", *sourcePath)
 	fileContent, err := os.ReadFile(*sourcePath)
 	if err != nil {
-		log.Fatalf("Failed to read obfuscated file: %v", err)
+		log.Fatalf("Failed to read source file: %v", err)
+	}
+
+	uploadPayload := string(fileContent)
+	if *recipients != "" {
+		recipientKeys := splitRecipientKeys(*recipients)
+		parsedRecipients, err := parseRecipients(recipientKeys)
+		if err != nil {
+			log.Fatalf("Failed to parse --recipients: %v", err)
+		}
+		bundle, err := encryptBundle(fileContent, recipientKeys, parsedRecipients)
+		if err != nil {
+			fatalExit(4, "Failed to encrypt source file: %v", err)
+		}
+		uploadPayload, err = marshalBundle(bundle)
+		if err != nil {
+			log.Fatalf("Failed to encode encrypted bundle: %v", err)
+		}
+		fmt.Printf("Encrypted source file to %d recipient(s).\n", len(parsedRecipients))
 	}
 
 	// Upload the file and get a download link
-	fmt.Printf("Uploading obfuscated .env file to environment %s...\n", *envID)
+	fmt.Printf("Uploading %s to environment %s...\n", *sourcePath, *envID)
 	downloadLink, err := client.UploadObfuscatedEnvFile(
 		ctx,
 		*envID,
-		string(fileContent),
+		uploadPayload,
 	)
 	if err != nil {
 		log.Fatalf("Failed to upload file: %v", err)
@@ -146,9 +233,26 @@ func main() {
 	}
 	fmt.Printf("Downloaded %d bytes of environment data\n", len(downloadedContent))
 
+	// Decrypt downloadedContent if it's an EncryptedBundle; otherwise it's
+	// already the plaintext .env (the pre-existing behavior).
+	finalContent := downloadedContent
+	if bundle, ok, err := unmarshalBundle(downloadedContent); err != nil {
+		fatalExit(4, "Failed to parse downloaded bundle: %v", err)
+	} else if ok {
+		identity, err := loadOrGenerateIdentity(*identityPath)
+		if err != nil {
+			fatalExit(5, "Failed to load identity: %v", err)
+		}
+		plaintext, err := decryptBundle(bundle, identity)
+		if err != nil {
+			fatalExit(4, "Failed to decrypt downloaded bundle: %v", err)
+		}
+		finalContent = string(plaintext)
+	}
+
 	// Save the downloaded content to the destination file
 	fmt.Printf("Saving downloaded content to %s...\n", *destPath)
-	err = os.WriteFile(*destPath, []byte(downloadedContent), 0644)
+	err = os.WriteFile(*destPath, []byte(finalContent), 0644)
 	if err != nil {
 		log.Fatalf("Failed to save downloaded content: %v", err)
 	}
@@ -163,7 +267,7 @@ func main() {
 	// Display the loaded environment variables (masked for sensitive values)
 	fmt.Println("Successfully loaded environment variables:")
 	// Parse the env file to know which variables were loaded
-	loadedVars := parseEnvFile(downloadedContent)
+	loadedVars := parseEnvFile(finalContent)
 	for key := range loadedVars {
 		value := os.Getenv(key)
 		// Mask the value if it looks like a secret
@@ -176,6 +280,45 @@ func main() {
 	fmt.Println("Environment setup complete!")
 }
 
+// runGitHubActionsExport implements the "github-actions" subcommand: it
+// reads every variable from an environment and emits the GitHub Actions
+// workflow commands a later step in the same job reads them back from,
+// without ever writing a masked value unredacted to the log.
+//
+// Usage example:
+//
+//	./env-client github-actions --env-id env_abc123 --api-key your-api-key
+func runGitHubActionsExport(args []string) {
+	fs := flag.NewFlagSet("github-actions", flag.ExitOnError)
+	apiBaseURL := fs.String("api-url", "https://api.envmanagement.com/v1", "Base URL for the environment API")
+	apiKey := fs.String("api-key", "", "API key for authentication")
+	envID := fs.String("env-id", "", "Environment ID to export")
+	timeout := fs.Int("timeout", 30, "Timeout in seconds for API operations")
+	fs.Parse(args)
+
+	if *apiKey == "" {
+		*apiKey = os.Getenv("ENV_API_KEY")
+		if *apiKey == "" {
+			log.Fatal("API key is required. Provide it with --api-key flag or ENV_API_KEY environment variable")
+		}
+	}
+	if *envID == "" {
+		log.Fatal("Environment ID is required. Provide it with --env-id flag")
+	}
+
+	client := env_client.NewEnvClient(*apiBaseURL, *apiKey, &env_client.Options{
+		Timeout: time.Duration(*timeout) * time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout+5)*time.Second)
+	defer cancel()
+
+	if err := client.ExportToGitHubActions(ctx, *envID); err != nil {
+		log.Fatalf("Failed to export environment to GitHub Actions: %v", err)
+	}
+	fmt.Println("Environment exported to GitHub Actions.")
+}
+
 // parseEnvFile parses a .env file content into a map
 func parseEnvFile(content string) map[string]string {
 	result := make(map[string]string)
@@ -231,4 +374,4 @@ func maskSensitiveValue(value string) string {
 		return "****"
 	}
 	return value[:2] + "****" + value[len(value)-2:]
-}
\ No newline at end of file
+}
@@ -0,0 +1,300 @@
+// sync.go
+// Implements the `sync` subcommand: a three-way merge between the local
+// .env at --source (ours), the remote environment (theirs), and a stored
+// .env.base snapshot of the last-synced common ancestor. Unlike the
+// default upload/download flow, which simply overwrites, sync lets two
+// people (or a person and CI) both edit the same environment and combine
+// their changes instead of clobbering one side.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	env_client "dataforge/manager/client/env"
+)
+
+// mergeStrategy selects how threeWayMerge resolves a key changed
+// differently on both sides since base.
+type mergeStrategy string
+
+const (
+	strategyOurs   mergeStrategy = "ours"
+	strategyTheirs mergeStrategy = "theirs"
+	strategyPrompt mergeStrategy = "prompt"
+	strategyFail   mergeStrategy = "fail"
+)
+
+// runSync implements the "sync" subcommand.
+//
+// Usage example:
+//
+//	./env-client sync --env-id env_abc123 --source ./.env --base ./.env.base --strategy=prompt
+func runSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	apiBaseURL := fs.String("api-url", "https://api.envmanagement.com/v1", "Base URL for the environment API")
+	apiKey := fs.String("api-key", "", "API key for authentication")
+	envID := fs.String("env-id", "", "Environment ID to sync")
+	sourcePath := fs.String("source", "./.env", "Path to the local plaintext .env file (the 'ours' side of the merge)")
+	basePath := fs.String("base", "./.env.base", "Path to the last-synced common-ancestor snapshot; treated as empty on first sync")
+	strategy := fs.String("strategy", "fail", "Conflict resolution for keys changed on both sides: ours|theirs|prompt|fail")
+	dryRun := fs.Bool("dry-run", false, "Print the merge diff without writing --source, --base, or the remote environment")
+	timeout := fs.Int("timeout", 30, "Timeout in seconds for API operations")
+	fs.Parse(args)
+
+	if *apiKey == "" {
+		*apiKey = os.Getenv("ENV_API_KEY")
+		if *apiKey == "" {
+			log.Fatal("API key is required. Provide it with --api-key flag or ENV_API_KEY environment variable")
+		}
+	}
+	if *envID == "" {
+		log.Fatal("Environment ID is required. Provide it with --env-id flag")
+	}
+
+	var strat mergeStrategy
+	switch *strategy {
+	case "ours":
+		strat = strategyOurs
+	case "theirs":
+		strat = strategyTheirs
+	case "prompt":
+		strat = strategyPrompt
+	case "fail":
+		strat = strategyFail
+	default:
+		log.Fatalf("Unknown --strategy %q; want ours, theirs, prompt, or fail", *strategy)
+	}
+
+	client := env_client.NewEnvClient(*apiBaseURL, *apiKey, &env_client.Options{
+		Timeout: time.Duration(*timeout) * time.Second,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout+5)*time.Second)
+	defer cancel()
+
+	fmt.Printf("Fetching remote environment %s...\n", *envID)
+	remoteContent, err := client.ExportEnvFile(ctx, *envID)
+	if err != nil {
+		log.Fatalf("Failed to fetch remote environment: %v", err)
+	}
+
+	local := parseEnvFile(readEnvFileOrEmpty(*sourcePath))
+	remote := parseEnvFile(remoteContent)
+	base := parseEnvFile(readEnvFileOrEmpty(*basePath))
+
+	merged, diff, unresolved := threeWayMerge(base, local, remote, strat, promptResolve)
+
+	if len(diff) == 0 {
+		fmt.Println("No differences; already in sync.")
+		return
+	}
+
+	fmt.Println("--- base")
+	fmt.Println("+++ merged")
+	for _, line := range diff {
+		fmt.Println(line)
+	}
+
+	if len(unresolved) > 0 {
+		fatalExit(1, "Unresolved conflicts on %d key(s) under --strategy=fail: %s. Re-run with --strategy=ours, --strategy=theirs, or --strategy=prompt.",
+			len(unresolved), strings.Join(unresolved, ", "))
+	}
+
+	if *dryRun {
+		fmt.Println("--dry-run: not writing --source, --base, or the remote environment.")
+		return
+	}
+
+	mergedContent := formatEnvFile(merged)
+
+	fmt.Printf("Writing merged environment to %s...\n", *sourcePath)
+	if err := os.WriteFile(*sourcePath, []byte(mergedContent), 0644); err != nil {
+		log.Fatalf("Failed to write merged environment to %q: %v", *sourcePath, err)
+	}
+
+	fmt.Println("Pushing merged environment to remote...")
+	if err := client.ImportEnvFile(ctx, *envID, mergedContent, true); err != nil {
+		log.Fatalf("Failed to push merged environment: %v", err)
+	}
+
+	fmt.Printf("Updating base snapshot at %s...\n", *basePath)
+	if err := os.WriteFile(*basePath, []byte(mergedContent), 0644); err != nil {
+		log.Fatalf("Failed to write base snapshot to %q: %v", *basePath, err)
+	}
+
+	fmt.Println("Sync complete!")
+}
+
+// threeWayMerge merges local ("ours") and remote ("theirs") changes made
+// since base, the last-synced common ancestor. A key changed identically
+// on both sides, or changed on only one side, merges without a conflict.
+// Deletion is modeled as key-absent, the same as any other value change,
+// so a key deleted on one side and modified on the other also conflicts.
+// Conflicts are resolved per strategy; resolve is only consulted for
+// strategyPrompt and may be nil otherwise.
+func threeWayMerge(base, local, remote map[string]string, strategy mergeStrategy, resolve func(key, localVal string, hasLocal bool, remoteVal string, hasRemote bool) (string, bool)) (merged map[string]string, diff []string, unresolved []string) {
+	keySet := make(map[string]struct{}, len(base)+len(local)+len(remote))
+	for k := range base {
+		keySet[k] = struct{}{}
+	}
+	for k := range local {
+		keySet[k] = struct{}{}
+	}
+	for k := range remote {
+		keySet[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	merged = make(map[string]string)
+	for _, key := range keys {
+		b, inBase := base[key]
+		l, inLocal := local[key]
+		r, inRemote := remote[key]
+
+		localChanged := inLocal != inBase || (inLocal && inBase && l != b)
+		remoteChanged := inRemote != inBase || (inRemote && inBase && r != b)
+
+		switch {
+		case !localChanged && !remoteChanged:
+			if inBase {
+				merged[key] = b
+			}
+			continue
+
+		case localChanged && !remoteChanged:
+			applyChange(merged, &diff, key, l, inLocal, b)
+			continue
+
+		case !localChanged && remoteChanged:
+			applyChange(merged, &diff, key, r, inRemote, b)
+			continue
+		}
+
+		// Both sides changed this key since base.
+		if inLocal && inRemote && l == r {
+			applyChange(merged, &diff, key, l, true, b)
+			continue
+		}
+		if !inLocal && !inRemote {
+			// Both sides independently deleted the key: an agreeing
+			// change, not a conflict.
+			applyChange(merged, &diff, key, "", false, b)
+			continue
+		}
+
+		switch strategy {
+		case strategyOurs:
+			applyChange(merged, &diff, key, l, inLocal, b)
+		case strategyTheirs:
+			applyChange(merged, &diff, key, r, inRemote, b)
+		case strategyPrompt:
+			value, keep := resolve(key, l, inLocal, r, inRemote)
+			applyChange(merged, &diff, key, value, keep, b)
+		default: // strategyFail
+			diff = append(diff, conflictLine(key, l, inLocal, r, inRemote))
+			unresolved = append(unresolved, key)
+		}
+	}
+	return merged, diff, unresolved
+}
+
+// applyChange records key's resolved value (or its deletion, if !present)
+// into merged and appends the corresponding diff line.
+func applyChange(merged map[string]string, diff *[]string, key, value string, present bool, base string) {
+	if present {
+		merged[key] = value
+		*diff = append(*diff, diffLine("+", key, value))
+		return
+	}
+	*diff = append(*diff, diffLine("-", key, base))
+}
+
+// diffLine formats one line of the unified-style key diff, masking
+// sensitive values the same way the rest of the CLI does.
+func diffLine(op, key, value string) string {
+	if isSensitiveKey(key) {
+		value = maskSensitiveValue(value)
+	}
+	return fmt.Sprintf("%s%s=%s", op, key, value)
+}
+
+// conflictLine formats a --strategy=fail conflict for display.
+func conflictLine(key, localVal string, hasLocal bool, remoteVal string, hasRemote bool) string {
+	return fmt.Sprintf("!%s local=%s remote=%s", key, sideDisplay(key, localVal, hasLocal), sideDisplay(key, remoteVal, hasRemote))
+}
+
+func sideDisplay(key, value string, present bool) string {
+	if !present {
+		return "<deleted>"
+	}
+	if isSensitiveKey(key) {
+		return maskSensitiveValue(value)
+	}
+	return value
+}
+
+// promptResolve asks the operator, on stdin/stdout, how to resolve one
+// conflicting key. It's the resolve callback for strategyPrompt.
+func promptResolve(key, localVal string, hasLocal bool, remoteVal string, hasRemote bool) (string, bool) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Conflict on %s: local=%s remote=%s. Keep [l]ocal, [r]emote, or [d]elete? ",
+			key, sideDisplay(key, localVal, hasLocal), sideDisplay(key, remoteVal, hasRemote))
+		line, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(strings.ToLower(line)) {
+		case "l":
+			return localVal, hasLocal
+		case "r":
+			return remoteVal, hasRemote
+		case "d":
+			return "", false
+		}
+		fmt.Println("Please answer l, r, or d.")
+	}
+}
+
+// readEnvFileOrEmpty reads a .env file, returning "" if it doesn't exist
+// yet — the expected state of --base before the first sync.
+func readEnvFileOrEmpty(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ""
+		}
+		log.Fatalf("Failed to read %q: %v", path, err)
+	}
+	return string(content)
+}
+
+// formatEnvFile serializes vars back into .env file format, key-sorted
+// for a stable diff across syncs. Values containing a space, a tab or '#'
+// are wrapped in double quotes, unescaped, so parseEnvFile round-trips them.
+func formatEnvFile(vars map[string]string) string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := vars[k]
+		if strings.ContainsAny(v, " \t#") {
+			v = "\"" + v + "\""
+		}
+		fmt.Fprintf(&b, "%s=%s\n", k, v)
+	}
+	return b.String()
+}
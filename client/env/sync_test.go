@@ -0,0 +1,136 @@
+package main
+
+import "testing"
+
+func TestThreeWayMergeNoChanges(t *testing.T) {
+	base := map[string]string{"A": "1"}
+	merged, diff, unresolved := threeWayMerge(base, base, base, strategyFail, nil)
+
+	if len(diff) != 0 {
+		t.Errorf("diff = %v, want none when nothing changed", diff)
+	}
+	if len(unresolved) != 0 {
+		t.Errorf("unresolved = %v, want none", unresolved)
+	}
+	if merged["A"] != "1" {
+		t.Errorf("merged[A] = %q, want %q", merged["A"], "1")
+	}
+}
+
+func TestThreeWayMergeKeepsNonConflictingChangesFromBothSides(t *testing.T) {
+	base := map[string]string{"A": "1", "B": "1"}
+	local := map[string]string{"A": "2", "B": "1"}  // A changed locally only.
+	remote := map[string]string{"A": "1", "B": "2"} // B changed remotely only.
+
+	merged, _, unresolved := threeWayMerge(base, local, remote, strategyFail, nil)
+
+	if len(unresolved) != 0 {
+		t.Fatalf("unresolved = %v, want none for non-conflicting changes", unresolved)
+	}
+	if merged["A"] != "2" {
+		t.Errorf("merged[A] = %q, want the local change %q", merged["A"], "2")
+	}
+	if merged["B"] != "2" {
+		t.Errorf("merged[B] = %q, want the remote change %q", merged["B"], "2")
+	}
+}
+
+func TestThreeWayMergeIdenticalChangeOnBothSidesNeverConflicts(t *testing.T) {
+	base := map[string]string{"A": "1"}
+	local := map[string]string{"A": "2"}
+	remote := map[string]string{"A": "2"}
+
+	merged, _, unresolved := threeWayMerge(base, local, remote, strategyFail, nil)
+
+	if len(unresolved) != 0 {
+		t.Fatalf("unresolved = %v, want none when both sides agree", unresolved)
+	}
+	if merged["A"] != "2" {
+		t.Errorf("merged[A] = %q, want %q", merged["A"], "2")
+	}
+}
+
+func TestThreeWayMergeAgreeingDeletionNeverConflicts(t *testing.T) {
+	base := map[string]string{"A": "1"}
+	local := map[string]string{}  // Deleted locally.
+	remote := map[string]string{} // Deleted remotely too.
+
+	merged, _, unresolved := threeWayMerge(base, local, remote, strategyFail, nil)
+
+	if len(unresolved) != 0 {
+		t.Fatalf("unresolved = %v, want none when both sides agree on deleting the key", unresolved)
+	}
+	if _, present := merged["A"]; present {
+		t.Errorf("merged[A] = %q, want it deleted", merged["A"])
+	}
+}
+
+func TestThreeWayMergePropagatesDeletion(t *testing.T) {
+	base := map[string]string{"A": "1"}
+	local := map[string]string{} // Deleted locally.
+	remote := map[string]string{"A": "1"}
+
+	merged, _, unresolved := threeWayMerge(base, local, remote, strategyFail, nil)
+
+	if len(unresolved) != 0 {
+		t.Fatalf("unresolved = %v, want none for a one-sided deletion", unresolved)
+	}
+	if _, present := merged["A"]; present {
+		t.Errorf("merged[A] = %q, want it deleted", merged["A"])
+	}
+}
+
+func TestThreeWayMergeConflictStrategies(t *testing.T) {
+	base := map[string]string{"A": "1"}
+	local := map[string]string{"A": "local"}
+	remote := map[string]string{"A": "remote"}
+
+	t.Run("fail records an unresolved conflict", func(t *testing.T) {
+		_, _, unresolved := threeWayMerge(base, local, remote, strategyFail, nil)
+		if len(unresolved) != 1 || unresolved[0] != "A" {
+			t.Errorf("unresolved = %v, want [A]", unresolved)
+		}
+	})
+
+	t.Run("ours keeps the local value", func(t *testing.T) {
+		merged, _, unresolved := threeWayMerge(base, local, remote, strategyOurs, nil)
+		if len(unresolved) != 0 {
+			t.Fatalf("unresolved = %v, want none under --strategy=ours", unresolved)
+		}
+		if merged["A"] != "local" {
+			t.Errorf("merged[A] = %q, want %q", merged["A"], "local")
+		}
+	})
+
+	t.Run("theirs keeps the remote value", func(t *testing.T) {
+		merged, _, unresolved := threeWayMerge(base, local, remote, strategyTheirs, nil)
+		if len(unresolved) != 0 {
+			t.Fatalf("unresolved = %v, want none under --strategy=theirs", unresolved)
+		}
+		if merged["A"] != "remote" {
+			t.Errorf("merged[A] = %q, want %q", merged["A"], "remote")
+		}
+	})
+
+	t.Run("prompt defers to the resolve callback", func(t *testing.T) {
+		resolve := func(key, localVal string, hasLocal bool, remoteVal string, hasRemote bool) (string, bool) {
+			return "resolved-by-human", true
+		}
+		merged, _, unresolved := threeWayMerge(base, local, remote, strategyPrompt, resolve)
+		if len(unresolved) != 0 {
+			t.Fatalf("unresolved = %v, want none under --strategy=prompt", unresolved)
+		}
+		if merged["A"] != "resolved-by-human" {
+			t.Errorf("merged[A] = %q, want the resolve callback's value", merged["A"])
+		}
+	})
+}
+
+func TestFormatEnvFileQuotesValuesNeedingIt(t *testing.T) {
+	content := formatEnvFile(map[string]string{"PLAIN": "value", "SPACED": "has space"})
+
+	want := "PLAIN=value\nSPACED=\"has space\"\n"
+	if content != want {
+		t.Errorf("formatEnvFile = %q, want %q", content, want)
+	}
+}
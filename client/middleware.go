@@ -0,0 +1,294 @@
+package rest_client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Middleware wraps a RoundTripper to add cross-cutting behavior (tracing,
+// metrics, logging, circuit breaking, caching, ...) around every request the
+// client sends. Middlewares compose like http.Handler middleware: the
+// function returns a new RoundTripper that wraps `next`.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// WithTransport sets the base http.RoundTripper the client's middleware
+// chain is built on top of. Defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.baseTransport = rt
+	}
+}
+
+// WithMiddleware appends a Middleware to the client's transport chain.
+// Middlewares run in the order they're added: the first one added is the
+// outermost layer (sees the request first, the response last).
+//
+// Usage example:
+//
+//	client := rest_client.NewClient(
+//	    rest_client.WithMiddleware(rest_client.LoggingMiddleware(rest_client.LogOptions{})),
+//	    rest_client.WithMiddleware(rest_client.MetricsMiddleware(collector)),
+//	)
+func WithMiddleware(mw Middleware) ClientOption {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, mw)
+	}
+}
+
+// WithOnRequest registers a hook invoked immediately before each attempt is
+// sent (including retries).
+func WithOnRequest(fn func(*http.Request)) ClientOption {
+	return func(c *Client) { c.onRequest = fn }
+}
+
+// WithOnResponse registers a hook invoked after a response is received,
+// before retry eligibility is evaluated.
+func WithOnResponse(fn func(*http.Response)) ClientOption {
+	return func(c *Client) { c.onResponse = fn }
+}
+
+// WithOnError registers a hook invoked when an attempt fails at the
+// transport level (before any retry).
+func WithOnError(fn func(error)) ClientOption {
+	return func(c *Client) { c.onError = fn }
+}
+
+// buildTransport composes the configured middlewares around the base
+// transport, outermost-first, so the resulting http.RoundTripper can be
+// installed on the client's http.Client.
+func (c *Client) buildTransport() http.RoundTripper {
+	base := c.baseTransport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	rt := base
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		rt = c.middlewares[i](rt)
+	}
+	return rt
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// --- Tracing ---------------------------------------------------------------
+
+// Tracer is the minimal span-creation interface TracingMiddleware needs,
+// satisfied by an OpenTelemetry tracer wrapper. It's defined locally rather
+// than importing go.opentelemetry.io directly so this package has no hard
+// dependency on a specific tracing SDK version.
+type Tracer interface {
+	// StartSpan starts a span for the outgoing request and returns a
+	// traceparent header value (RFC W3C Trace Context) to propagate, plus a
+	// function to call with the final status code and duration when done.
+	StartSpan(req *http.Request) (traceparent string, end func(statusCode int, duration time.Duration))
+}
+
+// TracingMiddleware injects a W3C "traceparent" header on every request and
+// records status/duration on the span returned by the given Tracer.
+func TracingMiddleware(tracer Tracer) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			traceparent, end := tracer.StartSpan(req)
+			if traceparent != "" {
+				req.Header.Set("traceparent", traceparent)
+			}
+
+			resp, err := next.RoundTrip(req)
+
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			end(status, time.Since(start))
+
+			return resp, err
+		})
+	}
+}
+
+// Callers that want connection-level timings rather than a full span can
+// attach an httptrace.ClientTrace to the request context alongside
+// TracingMiddleware; nothing in this package does so. This blank reference
+// is the package's only use of net/http/httptrace.
+var _ = httptrace.ClientTrace{}
+
+// --- Metrics -----------------------------------------------------------------
+
+// MetricsCollector receives per-request observations. A Prometheus-backed
+// implementation would typically increment a CounterVec in Observe and
+// record Duration in a HistogramVec, both keyed by method+path template+status.
+type MetricsCollector interface {
+	Observe(method, pathTemplate string, statusCode int, duration time.Duration)
+}
+
+// MetricsMiddleware records request count and latency for every call,
+// keyed by method, a path template (to avoid high-cardinality label values
+// from path parameters), and response status code.
+func MetricsMiddleware(collector MetricsCollector) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			duration := time.Since(start)
+
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			} else if err != nil {
+				status = -1
+			}
+			collector.Observe(req.Method, pathTemplate(req.URL.Path), status, duration)
+
+			return resp, err
+		})
+	}
+}
+
+// pathTemplate collapses path segments that look like IDs (numeric, or
+// UUID-shaped) into a placeholder so metrics don't create one label series
+// per resource ID.
+func pathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		if looksLikeID(seg) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func looksLikeID(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	digits := 0
+	for _, r := range seg {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits == len(seg) || strings.Count(seg, "-") >= 4
+}
+
+// counterMetricsCollector is a dependency-free MetricsCollector suitable for
+// tests or callers that don't yet have a Prometheus registry wired up.
+type counterMetricsCollector struct {
+	mu      sync.Mutex
+	counts  map[string]int64
+	latency map[string]time.Duration
+}
+
+// NewInMemoryMetricsCollector returns a MetricsCollector that accumulates
+// counts and total latency in memory, keyed by "METHOD path status".
+func NewInMemoryMetricsCollector() MetricsCollector {
+	return &counterMetricsCollector{
+		counts:  make(map[string]int64),
+		latency: make(map[string]time.Duration),
+	}
+}
+
+func (m *counterMetricsCollector) Observe(method, pathTemplate string, statusCode int, duration time.Duration) {
+	key := fmt.Sprintf("%s %s %d", method, pathTemplate, statusCode)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[key]++
+	m.latency[key] += duration
+}
+
+// --- Logging -----------------------------------------------------------------
+
+// LogOptions configures LoggingMiddleware's structured output and which
+// header/body values get redacted before they're logged.
+type LogOptions struct {
+	// Logf is called once per request with a structured, single-line
+	// message. Defaults to printing the line to stdout with fmt.Printf.
+	Logf func(format string, args ...interface{})
+
+	// RedactHeaders lists header names (case-insensitive) whose values are
+	// replaced with "[REDACTED]". Defaults to {"Authorization", "Cookie"}.
+	RedactHeaders []string
+
+	// LogBodies controls whether the request body is included in the log
+	// line at all (it's never redacted field-by-field, only included or
+	// omitted wholesale). Response bodies are never logged.
+	LogBodies bool
+}
+
+// LoggingMiddleware logs method, URL, status, and duration for every
+// request, with sensitive headers redacted per LogOptions.
+func LoggingMiddleware(opts LogOptions) Middleware {
+	logf := opts.Logf
+	if logf == nil {
+		logf = defaultLogf
+	}
+	redact := opts.RedactHeaders
+	if redact == nil {
+		redact = []string{"Authorization", "Cookie"}
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			headers := redactedHeaders(req.Header, redact)
+
+			var reqBody string
+			if opts.LogBodies && req.Body != nil {
+				b, _ := io.ReadAll(req.Body)
+				req.Body = io.NopCloser(strings.NewReader(string(b)))
+				reqBody = string(b)
+			}
+
+			resp, err := next.RoundTrip(req)
+			duration := time.Since(start)
+
+			if err != nil {
+				logf("rest_client: %s %s headers=%v body=%q error=%v duration=%s", req.Method, req.URL.String(), headers, reqBody, err, duration)
+				return resp, err
+			}
+
+			logf("rest_client: %s %s headers=%v body=%q status=%d duration=%s", req.Method, req.URL.String(), headers, reqBody, resp.StatusCode, duration)
+			return resp, err
+		})
+	}
+}
+
+func redactedHeaders(h http.Header, redact []string) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		redacted := false
+		for _, r := range redact {
+			if strings.EqualFold(k, r) {
+				redacted = true
+				break
+			}
+		}
+		if redacted {
+			out[k] = []string{"[REDACTED]"}
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+var logCount int64
+
+func defaultLogf(format string, args ...interface{}) {
+	atomic.AddInt64(&logCount, 1)
+	fmt.Printf(format+"\n", args...)
+}
@@ -0,0 +1,363 @@
+package rest_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PaginationStyle selects how a paginated endpoint advances to its next
+// page.
+type PaginationStyle int
+
+const (
+	// PaginationCursor reads the next page's token from the response body's
+	// top-level "next_page_token" field, or from the TokenHeader response
+	// header when that is set, and sends it back in the TokenField query
+	// parameter.
+	PaginationCursor PaginationStyle = iota
+	// PaginationPageNumber increments a "page" query parameter each call.
+	PaginationPageNumber
+	// PaginationLinkHeader follows an RFC 5988 Link: rel="next" response header.
+	PaginationLinkHeader
+)
+
+// PaginationConfig describes how to walk a multi-page collection.
+type PaginationConfig struct {
+	Style PaginationStyle
+
+	// TokenField is the query parameter PaginationCursor sends the cursor
+	// in. The cursor itself is read from the response body's top-level
+	// "next_page_token" field, or from the TokenHeader response header when
+	// TokenHeader is set (the header then takes precedence).
+	TokenField  string
+	TokenHeader string
+
+	// LimitField is the query parameter name used to request a page size;
+	// left unset if the endpoint doesn't support it.
+	LimitField string
+	Limit      int
+
+	// PageParam is the query parameter name for PaginationPageNumber,
+	// defaulting to "page".
+	PageParam string
+
+	// LinkRel is the Link relation to follow for PaginationLinkHeader,
+	// defaulting to "next".
+	LinkRel string
+}
+
+// Pagination describes the position of a fetched page within its
+// collection, surfaced alongside each page's items.
+type Pagination struct {
+	CurrentPage int
+	TotalPages  int
+	NextToken   string
+}
+
+// Lister is implemented by resources that can be walked a page at a time.
+// Paginator consumes it to drive iteration; Client.Iterate is the built-in
+// implementation backed by Do.
+type Lister interface {
+	ListPage(ctx context.Context, pageToken string, page int) (items []json.RawMessage, pagination Pagination, err error)
+}
+
+// Paginator lazily fetches successive pages of T from a Lister, stopping
+// when a page reports no further token/page, context is cancelled, or an
+// error occurs.
+type Paginator[T any] struct {
+	lister Lister
+	cfg    PaginationConfig
+
+	nextToken string
+	nextPage  int
+	started   bool
+	done      bool
+}
+
+// NewPaginator constructs a Paginator over the given Lister.
+func NewPaginator[T any](lister Lister, cfg PaginationConfig) *Paginator[T] {
+	return &Paginator[T]{lister: lister, cfg: cfg, nextPage: 1}
+}
+
+// Next fetches and decodes the next page. It returns done=true once the
+// collection is exhausted; callers should stop iterating at that point
+// regardless of the returned (empty) slice.
+func (p *Paginator[T]) Next(ctx context.Context) (items []T, pagination Pagination, done bool, err error) {
+	if p.done {
+		return nil, Pagination{}, true, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, Pagination{}, true, err
+	}
+
+	raw, pg, err := p.lister.ListPage(ctx, p.nextToken, p.nextPage)
+	if err != nil {
+		return nil, Pagination{}, true, err
+	}
+
+	items = make([]T, 0, len(raw))
+	for _, r := range raw {
+		var v T
+		if err := json.Unmarshal(r, &v); err != nil {
+			return nil, Pagination{}, true, fmt.Errorf("failed to decode page item: %w", err)
+		}
+		items = append(items, v)
+	}
+
+	p.started = true
+	p.nextToken = pg.NextToken
+	p.nextPage++
+
+	switch p.cfg.Style {
+	case PaginationPageNumber:
+		p.done = len(raw) == 0 || (pg.TotalPages > 0 && p.nextPage > pg.TotalPages)
+	default:
+		p.done = pg.NextToken == ""
+	}
+
+	return items, pg, false, nil
+}
+
+// clientLister adapts Client.Iterate's (Request, PaginationConfig) pair into
+// the Lister interface Paginator expects.
+type clientLister struct {
+	client  *Client
+	request Request
+	cfg     PaginationConfig
+}
+
+// ListPage implements Lister by issuing request with the cursor/page applied
+// per cfg.Style, and extracting the next-page pointer from the response.
+func (l *clientLister) ListPage(ctx context.Context, pageToken string, page int) ([]json.RawMessage, Pagination, error) {
+	req := l.request
+	if req.QueryParams == nil {
+		req.QueryParams = map[string]string{}
+	} else {
+		clone := make(map[string]string, len(req.QueryParams))
+		for k, v := range req.QueryParams {
+			clone[k] = v
+		}
+		req.QueryParams = clone
+	}
+
+	switch l.cfg.Style {
+	case PaginationPageNumber:
+		param := l.cfg.PageParam
+		if param == "" {
+			param = "page"
+		}
+		req.QueryParams[param] = strconv.Itoa(page)
+	case PaginationCursor:
+		if pageToken != "" && l.cfg.TokenField != "" {
+			req.QueryParams[l.cfg.TokenField] = pageToken
+		}
+	}
+	client := l.client
+	if l.cfg.Style == PaginationLinkHeader && pageToken != "" {
+		// pageToken is the absolute URL from the previous page's Link header.
+		linked := *l.client
+		linked.baseURL = pageToken
+		client = &linked
+		req.Path, req.PathParams, req.Query, req.QueryStruct = "", nil, nil, nil
+		req.QueryParams = map[string]string{}
+	}
+	if l.cfg.LimitField != "" && l.cfg.Limit > 0 {
+		req.QueryParams[l.cfg.LimitField] = strconv.Itoa(l.cfg.Limit)
+	}
+
+	resp, err := client.Do(ctx, req)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+	if !resp.IsSuccess() {
+		return nil, Pagination{}, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var envelope struct {
+		Items      []json.RawMessage `json:"items"`
+		NextToken  string            `json:"next_page_token"`
+		TotalPages int               `json:"total_pages"`
+	}
+	items := []json.RawMessage{}
+	pagination := Pagination{CurrentPage: page}
+
+	if err := json.Unmarshal(resp.Body, &envelope); err == nil && envelope.Items != nil {
+		items = envelope.Items
+		pagination.NextToken = envelope.NextToken
+		pagination.TotalPages = envelope.TotalPages
+	} else {
+		// Fall back to a bare JSON array response.
+		var arr []json.RawMessage
+		if err := json.Unmarshal(resp.Body, &arr); err == nil {
+			items = arr
+		}
+	}
+
+	if l.cfg.Style == PaginationCursor && l.cfg.TokenHeader != "" {
+		pagination.NextToken = resp.Headers.Get(l.cfg.TokenHeader)
+	}
+	if l.cfg.Style == PaginationLinkHeader {
+		rel := l.cfg.LinkRel
+		if rel == "" {
+			rel = "next"
+		}
+		if next := parseLinkHeader(resp.Headers.Get("Link"), rel); next != "" {
+			pagination.NextToken = next
+		}
+	}
+
+	return items, pagination, nil
+}
+
+// parseLinkHeader extracts the URL for the given rel from an RFC 5988 Link
+// header, e.g. `<https://api.example.com/v1/users?page=2>; rel="next"`.
+func parseLinkHeader(header, rel string) string {
+	if header == "" {
+		return ""
+	}
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+		url := strings.Trim(strings.TrimSpace(segments[0]), "<>")
+		for _, attr := range segments[1:] {
+			attr = strings.TrimSpace(attr)
+			if attr == fmt.Sprintf(`rel="%s"`, rel) || attr == fmt.Sprintf("rel=%s", rel) {
+				return url
+			}
+		}
+	}
+	return ""
+}
+
+// Iterate returns a Paginator that lazily walks every page of request
+// according to cfg, decoding each item as json.RawMessage so callers can
+// unmarshal into whatever type they need.
+//
+// Usage example:
+//
+//	p := client.Iterate(ctx, rest_client.Request{Path: "/users"}, rest_client.PaginationConfig{
+//	    Style:      rest_client.PaginationCursor,
+//	    TokenField: "page_token",
+//	})
+//	for {
+//	    items, _, done, err := p.Next(ctx)
+//	    if err != nil || done {
+//	        break
+//	    }
+//	    ...
+//	}
+func (c *Client) Iterate(ctx context.Context, request Request, cfg PaginationConfig) *Paginator[json.RawMessage] {
+	return NewPaginator[json.RawMessage](&clientLister{client: c, request: request, cfg: cfg}, cfg)
+}
+
+// --- Typed resource helper ---------------------------------------------------
+
+// APIError is returned by Resource[T] methods for non-2xx responses so
+// callers can inspect the status code programmatically instead of parsing
+// an error string.
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %d - %s", e.StatusCode, string(e.Body))
+}
+
+// Resource is a generic, code-gen-friendly wrapper exposing basic CRUD
+// verbs for a JSON resource type T rooted at BasePath (e.g. "/users").
+type Resource[T any] struct {
+	client   *Client
+	BasePath string
+}
+
+// NewResource returns a Resource[T] bound to basePath on client.
+func NewResource[T any](client *Client, basePath string) *Resource[T] {
+	return &Resource[T]{client: client, BasePath: basePath}
+}
+
+// Get fetches a single resource by ID and decodes it into T.
+func (r *Resource[T]) Get(ctx context.Context, id string) (*T, error) {
+	resp, err := r.client.Get(ctx, fmt.Sprintf("%s/%s", r.BasePath, id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: resp.Body}
+	}
+	var v T
+	if err := resp.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode resource: %w", err)
+	}
+	return &v, nil
+}
+
+// List returns every item of T at BasePath, paginating via cfg until
+// exhausted.
+func (r *Resource[T]) List(ctx context.Context, cfg PaginationConfig) ([]T, error) {
+	paginator := NewPaginator[T](&clientLister{client: r.client, request: Request{Path: r.BasePath}, cfg: cfg}, cfg)
+
+	var all []T
+	for {
+		items, _, done, err := paginator.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if done {
+			break
+		}
+	}
+	return all, nil
+}
+
+// Create POSTs body to BasePath and decodes the created resource.
+func (r *Resource[T]) Create(ctx context.Context, body interface{}) (*T, error) {
+	resp, err := r.client.Post(ctx, r.BasePath, body, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: resp.Body}
+	}
+	var v T
+	if err := resp.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode created resource: %w", err)
+	}
+	return &v, nil
+}
+
+// Update PUTs body to BasePath/id and decodes the updated resource.
+func (r *Resource[T]) Update(ctx context.Context, id string, body interface{}) (*T, error) {
+	resp, err := r.client.Put(ctx, fmt.Sprintf("%s/%s", r.BasePath, id), body, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: resp.Body}
+	}
+	var v T
+	if err := resp.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode updated resource: %w", err)
+	}
+	return &v, nil
+}
+
+// Delete removes BasePath/id.
+func (r *Resource[T]) Delete(ctx context.Context, id string) error {
+	resp, err := r.client.Delete(ctx, fmt.Sprintf("%s/%s", r.BasePath, id), nil)
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccess() {
+		return &APIError{StatusCode: resp.StatusCode, Body: resp.Body}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -26,6 +27,14 @@ type Client struct {
 	apiKeyHeaderName string             // Header name used to pass the API key (e.g., "Authorization", "X-API-Key")
 	headers          map[string]string  // Default headers applied to every request
 	timeout          time.Duration      // Timeout duration for all requests
+	retryPolicy      RetryPolicy        // Policy controlling retry/backoff behavior for Do
+	auth             AuthProvider       // Optional auth subsystem; applied after default headers, can override apiKey
+	baseTransport    http.RoundTripper  // Transport the middleware chain is built on; defaults to http.DefaultTransport
+	middlewares      []Middleware       // RoundTripper middleware chain, outermost first
+	onRequest        func(*http.Request)  // Lifecycle hook invoked before each attempt is sent
+	onResponse       func(*http.Response) // Lifecycle hook invoked after each response is received
+	onError          func(error)          // Lifecycle hook invoked when an attempt fails at the transport level
+	queryEncoder     QueryEncoder         // Encodes Request.QueryStruct into url.Values; defaults to DefaultQueryEncoder{}
 }
 
 // NewClient creates a new REST client with provided configuration options.
@@ -54,6 +63,8 @@ func NewClient(options ...ClientOption) *Client {
 		headers:          make(map[string]string),
 		apiKeyHeaderName: "Authorization",
 		timeout:          30 * time.Second,
+		retryPolicy:      DefaultRetryPolicy(),
+		queryEncoder:     DefaultQueryEncoder{},
 	}
 
 	// Apply configuration options
@@ -63,6 +74,7 @@ func NewClient(options ...ClientOption) *Client {
 
 	// Set timeout for the HTTP client
 	client.httpClient.Timeout = client.timeout
+	client.httpClient.Transport = client.buildTransport()
 
 	return client
 }
@@ -170,14 +182,52 @@ func WithHeader(key, value string) ClientOption {
 	}
 }
 
+// WithRetry configures the retry/backoff policy applied to every request
+// made through Do. By default, clients use DefaultRetryPolicy(); passing an
+// empty RetryPolicy{} (MaxAttempts == 0) disables retries entirely.
+//
+// Usage example:
+//
+//	client := rest_client.NewClient(
+//	    rest_client.WithRetry(rest_client.RetryPolicy{
+//	        MaxAttempts: 5,
+//	        BaseDelay:   200 * time.Millisecond,
+//	        MaxDelay:    10 * time.Second,
+//	        Jitter:      100 * time.Millisecond,
+//	    }),
+//	)
+//
+// Parameters:
+//   - policy: the RetryPolicy to apply to all requests made by this client.
+//
+// Returns:
+//   - ClientOption: a function to set the retry policy on the client.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
 // Request represents a generic API request.
 // It encapsulates all the information needed to make an HTTP request.
 type Request struct {
 	Method      string            // HTTP method (GET, POST, PUT, DELETE, PATCH, etc.)
-	Path        string            // API endpoint path (appended to baseURL, e.g., "/users")
+	Path        string            // API endpoint path (appended to baseURL, e.g., "/users/{id}")
+	PathParams  map[string]string // Values substituted into "{name}" placeholders in Path, percent-encoded per RFC 3986
 	QueryParams map[string]string // Optional URL query parameters (e.g., {"page": "1", "limit": "10"})
+	Query       url.Values        // Optional multi-value query parameters; merged with QueryParams
+	QueryStruct interface{}       // Optional struct encoded via the client's QueryEncoder (default: `url:"name,omitempty"` tags)
 	Body        interface{}       // Optional request body (usually a struct or map to be JSON-encoded)
 	Headers     map[string]string // Optional request-specific headers that override default ones
+	Retryable   *bool             // Overrides the client's default idempotency-based retry eligibility for this request
+	framer      Framer            // Stream framer used by Watch; set via WithFramer, defaults to NDJSONFramer
+}
+
+// RetryableRequest can be implemented by a Request.Body to force a non-idempotent
+// method (POST/PATCH) to be retried even when the client's policy does not opt
+// every method in by default.
+type RetryableRequest interface {
+	Retryable() bool
 }
 
 // Response represents a generic API response.
@@ -186,6 +236,7 @@ type Response struct {
 	StatusCode int         // HTTP status code (e.g., 200, 404, 500)
 	Headers    http.Header // Response headers map
 	Body       []byte      // Raw response body as a byte array
+	Attempts   int         // Number of attempts made before this response was returned (1 if no retry occurred)
 }
 
 // Do sends an HTTP request and returns a structured response.
@@ -233,73 +284,159 @@ type Response struct {
 //   - Network or timeout errors on request execution.
 //   - Errors while reading response body.
 func (c *Client) Do(ctx context.Context, request Request) (*Response, error) {
-	url := c.baseURL
-	if request.Path != "" {
-		url = fmt.Sprintf("%s%s", c.baseURL, request.Path)
+	resp, err := c.do(ctx, request)
+
+	// A single re-auth + retry when the auth provider supports discarding
+	// its cached credential and the server tells us it's stale.
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.auth != nil {
+		if reauth, ok := c.auth.(Reauthenticator); ok {
+			reauth.Reauthenticate()
+			resp, err = c.do(ctx, request)
+		}
 	}
 
-	// Append query parameters
-	if len(request.QueryParams) > 0 {
-		url += "?"
-		for key, value := range request.QueryParams {
-			url = fmt.Sprintf("%s%s=%s&", url, key, value)
-		}
-		url = url[:len(url)-1] // remove trailing '&'
+	return resp, err
+}
+
+// do performs the retry loop described by Do. It is split out so Do can wrap
+// it with the 401 reauthenticate-and-retry hook without double-counting
+// attempts in that second pass.
+func (c *Client) do(ctx context.Context, request Request) (*Response, error) {
+	reqURL, err := c.buildURL(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request URL: %w", err)
 	}
+	url := reqURL
 
-	// Prepare request body
-	var reqBody io.Reader
+	// Prepare request body once; attempts are replayed from this buffer so
+	// retries don't need to re-marshal or re-read a streaming source.
+	var bodyBytes []byte
 	if request.Body != nil {
 		jsonBody, err := json.Marshal(request.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
+		bodyBytes = jsonBody
 	}
 
-	// Construct HTTP request
-	req, err := http.NewRequestWithContext(ctx, request.Method, url, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	policy := c.retryPolicy
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var reqBody io.Reader
+		if bodyBytes != nil {
+			reqBody = bytes.NewReader(bodyBytes)
+		}
 
-	// Apply API key if provided
-	if c.apiKey != "" {
-		req.Header.Set(c.apiKeyHeaderName, c.apiKey)
-	}
+		req, err := http.NewRequestWithContext(ctx, request.Method, url, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	// Apply default headers
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
-	}
+		// Set headers
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
 
-	// Apply request-specific headers (override defaults)
-	for key, value := range request.Headers {
-		req.Header.Set(key, value)
-	}
+		// Apply API key if provided
+		if c.apiKey != "" {
+			req.Header.Set(c.apiKeyHeaderName, c.apiKey)
+		}
 
-	// Perform HTTP request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+		// Apply default headers
+		for key, value := range c.headers {
+			req.Header.Set(key, value)
+		}
 
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		// Apply auth after default headers so it can override them (e.g. a
+		// bearer token superseding a statically configured Authorization header).
+		if c.auth != nil {
+			if err := c.auth.Apply(ctx, req); err != nil {
+				return nil, fmt.Errorf("failed to apply auth: %w", err)
+			}
+		}
+
+		// Apply request-specific headers (override defaults)
+		for key, value := range request.Headers {
+			req.Header.Set(key, value)
+		}
+
+		if c.onRequest != nil {
+			c.onRequest(req)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request failed: %w", err)
+			if c.onError != nil {
+				c.onError(err)
+			}
+			if attempt == maxAttempts || !policy.shouldRetryError(request, err) {
+				return nil, lastErr
+			}
+			if !c.wait(ctx, policy.backoff(attempt)) {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		if c.onResponse != nil {
+			c.onResponse(resp)
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read response body: %w", err)
+			if attempt == maxAttempts {
+				return nil, lastErr
+			}
+			if !c.wait(ctx, policy.backoff(attempt)) {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		result := &Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header,
+			Body:       respBody,
+			Attempts:   attempt,
+		}
+
+		if attempt == maxAttempts || !policy.shouldRetryStatus(request, resp.StatusCode) {
+			return result, nil
+		}
+
+		delay := policy.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = retryAfter
+		}
+		if !c.wait(ctx, delay) {
+			return nil, ctx.Err()
+		}
 	}
 
-	return &Response{
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Header,
-		Body:       respBody,
-	}, nil
+	return nil, lastErr
+}
+
+// wait sleeps for d, returning false early if ctx is cancelled before the
+// sleep completes.
+func (c *Client) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
 
 // Get sends a GET request to the specified path.
@@ -548,82 +685,5 @@ func (r *Response) IsSuccess() bool {
 	return r.StatusCode >= 200 && r.StatusCode < 300
 }
 
-// UploadFile uploads a file to the specified path.
-// This method supports binary uploads with specified content type.
-//
-// Usage example:
-//
-//	file, err := os.Open("document.pdf")
-//	if err != nil {
-//	    return err
-//	}
-//	defer file.Close()
-//
-//	err = client.UploadFile("/documents/upload", file, "application/pdf")
-//	if err != nil {
-//	    return fmt.Errorf("upload failed: %w", err)
-//	}
-//
-// Parameters:
-//   - path: endpoint path for the upload (e.g., "/documents/upload").
-//   - file: io.Reader containing the file data to upload.
-//   - contentType: MIME type of the file being uploaded (e.g., "application/pdf", "image/jpeg").
-//
-// Returns:
-//   - error: if the upload fails for any reason.
-func (c *Client) UploadFile(path string, file io.Reader, contentType string) error {
-	reqURL, _ := c.BaseURL.Parse(path)
-	req, err := http.NewRequest("PUT", reqURL.String(), file)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed: %s", string(body))
-	}
-	return nil
-}
-
-// DownloadFile downloads a file from the specified path.
-// The file is returned as a byte array, which can be written to disk or processed in memory.
-//
-// Usage example:
-//
-//	data, err := client.DownloadFile("/documents/123/download")
-//	if err != nil {
-//	    return err
-//	}
-//
-//	// Write to file
-//	err = os.WriteFile("downloaded-document.pdf", data, 0644)
-//	if err != nil {
-//	    return fmt.Errorf("failed to save file: %w", err)
-//	}
-//
-// Parameters:
-//   - path: endpoint path for the download (e.g., "/documents/123/download").
-//
-// Returns:
-//   - []byte: byte array containing the downloaded file data.
-//   - error: if the download fails for any reason.
-func (c *Client) DownloadFile(path string) ([]byte, error) {
-	reqURL, _ := c.BaseURL.Parse(path)
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
-}
\ No newline at end of file
+// UploadFile, DownloadFile, and the chunked resumable upload protocol live in
+// transfer.go, which also documents the full file-transfer subsystem.
\ No newline at end of file
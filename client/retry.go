@@ -0,0 +1,166 @@
+package rest_client
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls whether and how Do retries a failed request. It is
+// modeled on Vault's MaxRetries option and client-go's URLBackoff: a bounded
+// number of attempts, exponential backoff with jitter between them, and an
+// explicit allow-list of what counts as "retryable".
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts (including the first),
+	// not the number of retries. MaxAttempts <= 1 disables retrying.
+	MaxAttempts int
+
+	// BaseDelay and MaxDelay bound the exponential backoff: the delay after
+	// failed attempt N (before attempt N+1) is min(MaxDelay, BaseDelay *
+	// 2^(N-1)) plus jitter.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Jitter is the maximum random duration added to each computed delay.
+	Jitter time.Duration
+
+	// RetryableStatusCodes lists the HTTP status codes that should be
+	// retried. Defaults to 429, 502, 503, 504.
+	RetryableStatusCodes []int
+
+	// RetryableError classifies a transport-level error (one returned by
+	// http.Client.Do) as retryable. Defaults to isTemporaryNetworkError.
+	RetryableError func(error) bool
+
+	// RetryNonIdempotent allows POST/PATCH requests to be retried even
+	// without a per-request opt-in via Request.Retryable or
+	// RetryableRequest. Off by default since replaying a non-idempotent
+	// call can duplicate side effects.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used by NewClient when no
+// WithRetry option is supplied: up to 3 attempts, 100ms-2s exponential
+// backoff with up to 250ms of jitter, retrying 429/502/503/504 and
+// idempotent methods only.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            100 * time.Millisecond,
+		MaxDelay:             2 * time.Second,
+		Jitter:               250 * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryableError:       isTemporaryNetworkError,
+	}
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+	http.MethodHead:   true,
+}
+
+// shouldRetryStatus reports whether resp's status code warrants a retry for
+// the given request, honoring both the policy's status list and the
+// request's idempotency.
+func (p RetryPolicy) shouldRetryStatus(req Request, statusCode int) bool {
+	if !p.requestIsRetryable(req) {
+		return false
+	}
+	codes := p.RetryableStatusCodes
+	if codes == nil {
+		codes = DefaultRetryPolicy().RetryableStatusCodes
+	}
+	for _, code := range codes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRetryError reports whether a transport-level error warrants a retry.
+func (p RetryPolicy) shouldRetryError(req Request, err error) bool {
+	if !p.requestIsRetryable(req) {
+		return false
+	}
+	if p.RetryableError == nil {
+		return isTemporaryNetworkError(err)
+	}
+	return p.RetryableError(err)
+}
+
+// requestIsRetryable decides, independent of status/error, whether this
+// particular request is allowed to be retried at all based on its method
+// and any opt-in markers.
+func (p RetryPolicy) requestIsRetryable(req Request) bool {
+	if req.Retryable != nil {
+		return *req.Retryable
+	}
+	if marker, ok := req.Body.(RetryableRequest); ok {
+		return marker.Retryable()
+	}
+	if idempotentMethods[req.Method] {
+		return true
+	}
+	return p.RetryNonIdempotent
+}
+
+// backoff computes the delay after the given attempt number (1-indexed) has
+// failed, before the next attempt, using exponential backoff with jitter,
+// capped at MaxDelay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	delay := time.Duration(float64(base) * math.Pow(2, float64(attempt-1)))
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+	return delay
+}
+
+// isTemporaryNetworkError classifies common transient network failures
+// (connection resets, timeouts, temporary DNS errors) as retryable.
+func isTemporaryNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header value, which per RFC 7231 may
+// be either a number of delta-seconds or an HTTP-date. It returns ok=false
+// if the header is absent or unparseable.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
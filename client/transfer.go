@@ -0,0 +1,432 @@
+package rest_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+)
+
+// UploadOptions configures UploadFile.
+type UploadOptions struct {
+	// ContentType is the MIME type of the file (e.g. "application/pdf").
+	// When Multipart is true it is the Content-Type of the primary file
+	// part; MultipartFiles parts are always sent as application/octet-stream.
+	ContentType string
+
+	// Multipart switches UploadFile from a raw PUT body to a
+	// multipart/form-data POST (RFC 7578). When true, the file reader
+	// passed to UploadFile is sent as MultipartFileField (default "file"),
+	// and MultipartFields/MultipartFiles add any additional form fields
+	// and files.
+	Multipart        bool
+	MultipartFileField string // defaults to "file"
+	MultipartFileName  string // defaults to "upload"
+	MultipartFields    map[string]string
+	MultipartFiles     map[string]io.Reader // extra file fields beyond the primary one
+
+	// Headers are merged into the request, overriding ContentType if set.
+	Headers map[string]string
+}
+
+// UploadFile uploads file to path, either as a raw PUT body or as
+// multipart/form-data depending on opts.Multipart. Multipart uploads are
+// streamed through an io.Pipe so large files are never buffered in memory.
+//
+// Usage example:
+//
+//	f, err := os.Open("document.pdf")
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//
+//	resp, err := client.UploadFile(ctx, "/documents/upload", f, rest_client.UploadOptions{
+//	    ContentType: "application/pdf",
+//	})
+//
+// Parameters:
+//   - ctx: context for cancellation/timeouts.
+//   - path: endpoint path for the upload.
+//   - file: the file content to upload.
+//   - opts: upload mode and any extra multipart fields/files.
+//
+// Returns:
+//   - *Response: the response object containing status, headers, and body.
+//   - error: if the upload fails for any reason.
+func (c *Client) UploadFile(ctx context.Context, path string, file io.Reader, opts UploadOptions) (*Response, error) {
+	if opts.Multipart {
+		return c.uploadMultipart(ctx, path, file, opts)
+	}
+	return c.uploadRaw(ctx, path, file, opts)
+}
+
+// uploadRaw sends file as the literal request body of a PUT.
+func (c *Client) uploadRaw(ctx context.Context, path string, file io.Reader, opts UploadOptions) (*Response, error) {
+	url := c.baseURL + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	if err := c.applyCommonHeaders(ctx, req); err != nil {
+		return nil, err
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	return c.doRawRequest(req)
+}
+
+// uploadMultipart streams file (and any MultipartFields/MultipartFiles) as a
+// multipart/form-data POST via an io.Pipe, so the whole payload never needs
+// to be materialized in memory at once.
+func (c *Client) uploadMultipart(ctx context.Context, path string, file io.Reader, opts UploadOptions) (*Response, error) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := func() error {
+			for key, value := range opts.MultipartFields {
+				if err := writer.WriteField(key, value); err != nil {
+					return err
+				}
+			}
+
+			fieldName := opts.MultipartFileField
+			if fieldName == "" {
+				fieldName = "file"
+			}
+			fileName := opts.MultipartFileName
+			if fileName == "" {
+				fileName = "upload"
+			}
+
+			part, err := createFormFilePart(writer, fieldName, fileName, opts.ContentType)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, file); err != nil {
+				return err
+			}
+
+			for field, reader := range opts.MultipartFiles {
+				extraPart, err := createFormFilePart(writer, field, field, "")
+				if err != nil {
+					return err
+				}
+				if _, err := io.Copy(extraPart, reader); err != nil {
+					return err
+				}
+			}
+
+			return writer.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multipart upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := c.applyCommonHeaders(ctx, req); err != nil {
+		pr.CloseWithError(err) // unblock the writer goroutine, which is already writing into pw
+		return nil, err
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	return c.doRawRequest(req)
+}
+
+// createFormFilePart mirrors multipart.Writer.CreateFormFile but lets the
+// caller set an explicit Content-Type instead of always using
+// application/octet-stream.
+func createFormFilePart(w *multipart.Writer, fieldName, fileName, contentType string) (io.Writer, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, fieldName, fileName))
+	header.Set("Content-Type", contentType)
+	return w.CreatePart(header)
+}
+
+// DownloadOptions configures DownloadFile.
+type DownloadOptions struct {
+	// Resume, when true, inspects how many bytes have already been written
+	// to w (via a RangeOffsetWriter or an *os.File whose current size is
+	// used) and issues `Range: bytes=N-` to continue an interrupted download.
+	Resume bool
+
+	Headers map[string]string
+}
+
+// RangeOffsetWriter lets callers tell DownloadFile how many bytes of a
+// partial download are already present in w, when w isn't an *os.File whose
+// size can be statted directly.
+type RangeOffsetWriter interface {
+	io.Writer
+	Offset() int64
+}
+
+// DownloadFile streams path's response body into w, honoring Range requests
+// for resumable downloads when opts.Resume is set.
+//
+// Usage example:
+//
+//	f, err := os.OpenFile("document.pdf", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//
+//	resp, err := client.DownloadFile(ctx, "/documents/123/download", f, rest_client.DownloadOptions{Resume: true})
+//
+// Parameters:
+//   - ctx: context for cancellation/timeouts.
+//   - path: endpoint path for the download.
+//   - w: destination for the downloaded bytes.
+//   - opts: resume behavior and any extra headers.
+//
+// Returns:
+//   - *Response: status/headers of the response (Body is empty; content streamed to w).
+//   - error: if the download fails for any reason.
+func (c *Client) DownloadFile(ctx context.Context, path string, w io.Writer, opts DownloadOptions) (*Response, error) {
+	url := c.baseURL + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+	if err := c.applyCommonHeaders(ctx, req); err != nil {
+		return nil, err
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if opts.Resume {
+		if offset, ok := currentOffset(w); ok && offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return &Response{StatusCode: resp.StatusCode, Headers: resp.Header, Body: body}, fmt.Errorf("download failed: %d - %s", resp.StatusCode, string(body))
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to stream download: %w", err)
+	}
+
+	return &Response{StatusCode: resp.StatusCode, Headers: resp.Header}, nil
+}
+
+// currentOffset figures out how many bytes of w already hold data, for
+// resuming a download, supporting *os.File and the RangeOffsetWriter
+// extension point.
+func currentOffset(w io.Writer) (int64, bool) {
+	switch v := w.(type) {
+	case RangeOffsetWriter:
+		return v.Offset(), true
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil {
+			return 0, false
+		}
+		return info.Size(), true
+	default:
+		return 0, false
+	}
+}
+
+// applyCommonHeaders sets the headers transfer requests share with Do:
+// default headers, API key / auth.
+func (c *Client) applyCommonHeaders(ctx context.Context, req *http.Request) error {
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if c.apiKey != "" {
+		req.Header.Set(c.apiKeyHeaderName, c.apiKey)
+	}
+	if c.auth != nil {
+		if err := c.auth.Apply(ctx, req); err != nil {
+			return fmt.Errorf("failed to apply auth: %w", err)
+		}
+	}
+	return nil
+}
+
+// doRawRequest executes req through the client's http.Client (picking up
+// the configured transport/middleware chain) and wraps the result as a
+// Response, without the JSON-oriented marshaling Do performs.
+func (c *Client) doRawRequest(req *http.Request) (*Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return &Response{StatusCode: resp.StatusCode, Headers: resp.Header, Body: body}, fmt.Errorf("upload failed: %d - %s", resp.StatusCode, string(body))
+	}
+
+	return &Response{StatusCode: resp.StatusCode, Headers: resp.Header, Body: body}, nil
+}
+
+// --- tus.io-style chunked resumable upload ----------------------------------
+
+// ChunkProgressFunc reports bytes uploaded so far against the total, called
+// after each successful chunk PATCH.
+type ChunkProgressFunc func(bytesUploaded, totalBytes int64)
+
+// ChunkedUpload implements a tus.io-like resumable upload protocol: POST to
+// create an upload (reserving TotalBytes), then PATCH successive chunks with
+// Upload-Offset, and HEAD to discover the current offset when resuming an
+// interrupted transfer.
+type ChunkedUpload struct {
+	// CreatePath is the endpoint that creates a new upload resource and
+	// returns its location in the "Location" response header.
+	CreatePath string
+
+	ChunkSize int64 // defaults to 4MiB
+	Progress  ChunkProgressFunc
+}
+
+// Upload creates a chunked upload of r (or resumes one when resumeURL, a
+// prior attempt's upload URL, is non-empty), which must report TotalBytes in
+// total, and returns the final upload URL.
+//
+// Parameters:
+//   - ctx: context for cancellation/timeouts.
+//   - r: the source data to upload; must be exactly totalBytes long.
+//   - totalBytes: total size of r, sent as Upload-Length on creation.
+//   - resumeURL: if non-empty, resumes a previously created upload at this
+//     URL instead of creating a new one.
+//
+// Returns:
+//   - string: the upload's resource URL (reuse as resumeURL to continue later).
+//   - error: if creation, a chunk PATCH, or the resume HEAD fails.
+func (cu *ChunkedUpload) Upload(ctx context.Context, c *Client, r io.Reader, totalBytes int64, resumeURL string) (string, error) {
+	chunkSize := cu.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 4 << 20
+	}
+
+	uploadURL := resumeURL
+	var offset int64
+
+	if uploadURL == "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+cu.CreatePath, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create chunked upload request: %w", err)
+		}
+		req.Header.Set("Upload-Length", strconv.FormatInt(totalBytes, 10))
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		if err := c.applyCommonHeaders(ctx, req); err != nil {
+			return "", err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to create upload: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return "", fmt.Errorf("create upload returned %d", resp.StatusCode)
+		}
+		uploadURL = resp.Header.Get("Location")
+		if uploadURL == "" {
+			return "", fmt.Errorf("server did not return a Location header for the new upload")
+		}
+	} else {
+		headReq, err := http.NewRequestWithContext(ctx, http.MethodHead, uploadURL, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create resume HEAD request: %w", err)
+		}
+		headReq.Header.Set("Tus-Resumable", "1.0.0")
+		if err := c.applyCommonHeaders(ctx, headReq); err != nil {
+			return "", err
+		}
+
+		resp, err := c.httpClient.Do(headReq)
+		if err != nil {
+			return "", fmt.Errorf("failed to query upload offset: %w", err)
+		}
+		resp.Body.Close()
+		offset, _ = strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	}
+
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+			return "", fmt.Errorf("failed to seek source to resume offset: %w", err)
+		}
+	}
+
+	buf := make([]byte, chunkSize)
+	for offset < totalBytes {
+		n, readErr := io.ReadFull(r, buf)
+		if n == 0 && readErr != nil {
+			return "", fmt.Errorf("failed to read chunk: %w", readErr)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPatch, uploadURL, bytes.NewReader(buf[:n]))
+		if err != nil {
+			return "", fmt.Errorf("failed to create chunk PATCH request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		if err := c.applyCommonHeaders(ctx, req); err != nil {
+			return "", err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("chunk upload failed at offset %d: %w", offset, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return "", fmt.Errorf("chunk upload at offset %d returned %d", offset, resp.StatusCode)
+		}
+
+		offset += int64(n)
+		if cu.Progress != nil {
+			cu.Progress(offset, totalBytes)
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	return uploadURL, nil
+}
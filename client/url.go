@@ -0,0 +1,217 @@
+package rest_client
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// QueryEncoder turns an arbitrary struct into url.Values, similar in spirit
+// to google/go-querystring. Set via WithQueryEncoder to customize how
+// Request.QueryStruct is encoded.
+type QueryEncoder interface {
+	Encode(v interface{}) (url.Values, error)
+}
+
+// WithQueryEncoder overrides the QueryEncoder used to encode
+// Request.QueryStruct. Defaults to DefaultQueryEncoder{}.
+func WithQueryEncoder(encoder QueryEncoder) ClientOption {
+	return func(c *Client) {
+		c.queryEncoder = encoder
+	}
+}
+
+// DefaultQueryEncoder encodes exported struct fields into url.Values using
+// an `url:"name,omitempty"` tag, falling back to the field's lowercased name
+// when no tag is present. A tag of "-" skips the field. Supported field
+// kinds: string, the integer/float/bool kinds, and slices of those (encoded
+// as repeated keys).
+type DefaultQueryEncoder struct{}
+
+// Encode implements QueryEncoder.
+func (DefaultQueryEncoder) Encode(v interface{}) (url.Values, error) {
+	values := url.Values{}
+	if v == nil {
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query encoder: expected a struct or pointer to struct, got %s", rv.Kind())
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name, omitempty, skip := parseURLTag(field)
+		if skip {
+			continue
+		}
+
+		fieldValue := rv.Field(i)
+		if omitempty && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Slice {
+			for j := 0; j < fieldValue.Len(); j++ {
+				values.Add(name, formatValue(fieldValue.Index(j)))
+			}
+			continue
+		}
+
+		values.Add(name, formatValue(fieldValue))
+	}
+
+	return values, nil
+}
+
+func parseURLTag(field reflect.StructField) (name string, omitempty bool, skip bool) {
+	tag := field.Tag.Get("url")
+	if tag == "-" {
+		return "", false, true
+	}
+	if tag == "" {
+		return strings.ToLower(field.Name), false, false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
+
+// buildURL joins the client's baseURL with request.Path via net/url (so
+// percent-encoding, and leading/trailing slash handling, are correct
+// regardless of how either is formatted), substitutes PathParams into
+// "{name}" placeholders, and appends QueryParams/Query/QueryStruct.
+func (c *Client) buildURL(request Request) (string, error) {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", c.baseURL, err)
+	}
+
+	path := substitutePathParams(request.Path, request.PathParams)
+
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", path, err)
+	}
+
+	resolved := joinURL(base, ref)
+
+	query := resolved.Query()
+	for key, value := range request.QueryParams {
+		query.Set(key, value)
+	}
+	for key, values := range request.Query {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	if request.QueryStruct != nil {
+		encoder := c.queryEncoder
+		if encoder == nil {
+			encoder = DefaultQueryEncoder{}
+		}
+		encoded, err := encoder.Encode(request.QueryStruct)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode query struct: %w", err)
+		}
+		for key, values := range encoded {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+	}
+	resolved.RawQuery = query.Encode()
+
+	return resolved.String(), nil
+}
+
+// joinURL concatenates base and ref's paths, collapsing the doubled slash
+// that naive string concatenation produces when base ends in "/" and ref
+// starts with "/" (or the missing slash when neither does).
+func joinURL(base, ref *url.URL) *url.URL {
+	result := *base
+	if ref.Path != "" {
+		result.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(ref.Path, "/")
+		result.RawPath = ""
+		if base.RawPath != "" || ref.RawPath != "" {
+			result.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(ref.EscapedPath(), "/")
+		}
+	}
+	if ref.RawQuery != "" {
+		result.RawQuery = ref.RawQuery
+	}
+	return &result
+}
+
+// substitutePathParams replaces "{name}" placeholders in path with
+// percent-encoded values from params.
+func substitutePathParams(path string, params map[string]string) string {
+	if len(params) == 0 {
+		return path
+	}
+	for name, value := range params {
+		placeholder := "{" + name + "}"
+		path = strings.ReplaceAll(path, placeholder, url.PathEscape(value))
+	}
+	return path
+}
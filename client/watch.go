@@ -0,0 +1,287 @@
+package rest_client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Event is a single decoded item from a watch stream: an action type (e.g.
+// "ADDED", "MODIFIED", "DELETED", mirroring client-go's watch.Event) paired
+// with the raw JSON object it applies to. Err is set instead of Object when
+// reading the stream failed; that event is the last one before ResultChan
+// closes. No framer reports a decode error through Err.
+type Event struct {
+	Type   string
+	Object json.RawMessage
+	Err    error
+}
+
+// Watcher exposes a live channel of Events for a long-lived request. Callers
+// must call Stop when done to release the underlying connection; ResultChan
+// is closed after Stop or when the stream ends (EOF/error).
+type Watcher interface {
+	ResultChan() <-chan Event
+	Stop()
+}
+
+// Framer splits a response body into individual frames and decodes each into
+// an Event. Implementations should be tolerant of a frame split across reads;
+// Next is called repeatedly until it returns io.EOF.
+type Framer interface {
+	Next(r *bufio.Reader) (Event, error)
+}
+
+// WithFramer selects the stream format used to decode a Watch response body.
+// Defaults to NDJSONFramer{} when unset.
+func WithFramer(f Framer) RequestOption {
+	return func(r *Request) {
+		r.framer = f
+	}
+}
+
+// RequestOption configures a single Request, analogous to ClientOption for
+// the Client itself. It's used by watch-related settings that don't make
+// sense as plain Request struct fields (e.g. pluggable framers).
+type RequestOption func(*Request)
+
+// NDJSONFramer decodes newline-delimited JSON objects, one Event per line.
+// A line of the form {"type": ..., "object": ...} yields that Type and
+// Object; any other line is carried whole as Object with Type left empty.
+type NDJSONFramer struct{}
+
+// Next implements Framer.
+func (NDJSONFramer) Next(r *bufio.Reader) (Event, error) {
+	line, err := r.ReadBytes('\n')
+	if len(line) == 0 && err != nil {
+		return Event{}, err
+	}
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		if err != nil {
+			return Event{}, err
+		}
+		return Event{}, nil
+	}
+
+	var envelope struct {
+		Type   string          `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	if decodeErr := json.Unmarshal(line, &envelope); decodeErr != nil {
+		// Not an enveloped {type, object} frame; treat the whole line as the object.
+		return Event{Object: json.RawMessage(line)}, err
+	}
+	if envelope.Object == nil {
+		return Event{Object: json.RawMessage(line)}, err
+	}
+	return Event{Type: envelope.Type, Object: envelope.Object}, err
+}
+
+// SSEFramer decodes Server-Sent Events frames ("event: ...\ndata: ...\n\n"),
+// collapsing multi-line `data:` fields per the SSE spec.
+type SSEFramer struct{}
+
+// Next implements Framer.
+func (SSEFramer) Next(r *bufio.Reader) (Event, error) {
+	var eventType string
+	var data strings.Builder
+
+	for {
+		line, err := r.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if trimmed == "" {
+			if data.Len() > 0 {
+				return Event{Type: eventType, Object: json.RawMessage(data.String())}, nil
+			}
+			if err != nil {
+				return Event{}, err
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(trimmed, "event:"))
+		case strings.HasPrefix(trimmed, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(trimmed, "data:")))
+		}
+
+		if err != nil {
+			if data.Len() > 0 {
+				return Event{Type: eventType, Object: json.RawMessage(data.String())}, nil
+			}
+			return Event{}, err
+		}
+	}
+}
+
+// LengthPrefixedFramer decodes frames of the form [4-byte big-endian length
+// byte][payload], suitable for length-prefixed protobuf streams. The payload
+// is carried through as Object verbatim (not JSON-decoded) since protobuf
+// isn't JSON; callers unmarshal it with their own message type.
+type LengthPrefixedFramer struct{}
+
+// Next implements Framer.
+func (LengthPrefixedFramer) Next(r *bufio.Reader) (Event, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return Event{}, err
+	}
+	size := binary.BigEndian.Uint32(lenBuf[:])
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return Event{}, err
+	}
+	return Event{Object: json.RawMessage(payload)}, nil
+}
+
+// watcher is the default Watcher implementation backing Watch.
+type watcher struct {
+	body   io.ReadCloser
+	events chan Event
+	stopCh chan struct{}
+	once   sync.Once
+}
+
+// ResultChan implements Watcher.
+func (w *watcher) ResultChan() <-chan Event {
+	return w.events
+}
+
+// Stop implements Watcher, closing the underlying connection so the read
+// loop unblocks and exits.
+func (w *watcher) Stop() {
+	w.once.Do(func() {
+		close(w.stopCh)
+		w.body.Close()
+	})
+}
+
+// Watch issues request without the client's normal timeout (long-lived
+// streams would otherwise be killed mid-flight) and decodes the response
+// body as a sequence of Events using request's Framer (NDJSONFramer by
+// default). The returned Watcher must be Stopped by the caller.
+//
+// Usage example:
+//
+//	w, err := client.Watch(ctx, rest_client.Request{Path: "/events"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer w.Stop()
+//	for ev := range w.ResultChan() {
+//	    fmt.Printf("%s: %s\n", ev.Type, ev.Object)
+//	}
+//
+// Parameters:
+//   - ctx: context governing the lifetime of the underlying connection.
+//   - request: the Request to issue; set request.framer via WithFramer to
+//     pick a non-default stream format.
+//
+// Returns:
+//   - Watcher: a live event stream; call Stop when done.
+//   - error: if the request could not be issued or did not succeed.
+func (c *Client) Watch(ctx context.Context, request Request, opts ...RequestOption) (Watcher, error) {
+	for _, opt := range opts {
+		opt(&request)
+	}
+
+	url := c.baseURL
+	if request.Path != "" {
+		url = c.baseURL + request.Path
+	}
+
+	method := request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watch request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set(c.apiKeyHeaderName, c.apiKey)
+	}
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if c.auth != nil {
+		if err := c.auth.Apply(ctx, req); err != nil {
+			return nil, fmt.Errorf("failed to apply auth: %w", err)
+		}
+	}
+	for key, value := range request.Headers {
+		req.Header.Set(key, value)
+	}
+
+	// Long-lived streams must not be subject to the client's request timeout.
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("watch request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("watch request returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	framer := request.framer
+	if framer == nil {
+		framer = NDJSONFramer{}
+	}
+
+	w := &watcher{
+		body:   resp.Body,
+		events: make(chan Event),
+		stopCh: make(chan struct{}),
+	}
+
+	go w.run(framer)
+
+	return w, nil
+}
+
+// run reads frames from the response body until EOF, Stop, or a fatal
+// decode error, emitting each as an Event and closing the channel on exit.
+func (w *watcher) run(framer Framer) {
+	defer close(w.events)
+	defer w.body.Close()
+
+	reader := bufio.NewReader(w.body)
+	for {
+		ev, err := framer.Next(reader)
+		if ev.Object != nil || ev.Err != nil {
+			select {
+			case w.events <- ev:
+			case <-w.stopCh:
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				select {
+				case w.events <- Event{Err: err}:
+				case <-w.stopCh:
+				}
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// bulk.go
+// Bulk variable operations (SetVariables, DeleteVariables, GetVariables) that
+// fan out per-key REST calls across a bounded worker pool, so migration
+// scripts syncing hundreds of keys don't pay for them one request at a time
+// or lose the whole batch to a single failing key.
+
+package env_client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BulkOptions configures SetVariables/DeleteVariables/GetVariables.
+type BulkOptions struct {
+	// Concurrency bounds how many keys are in flight at once. Defaults to 8
+	// if <= 0.
+	Concurrency int
+
+	// StopOnError cancels the context shared by every call as soon as any
+	// single key's call fails, instead of the default behavior of running
+	// every key to completion and reporting all the failures together.
+	// In-flight calls that honor ctx are aborted too; keys started later
+	// still call fn, with the canceled context.
+	StopOnError bool
+
+	// MaxAttempts is the number of attempts per key, including the first,
+	// before its error is recorded. Defaults to 3; <= 1 disables retrying.
+	MaxAttempts int
+
+	// BaseDelay, MaxDelay, and Jitter bound the exponential backoff between
+	// attempts for a single key, mirroring rest_client.RetryPolicy: the
+	// delay after failed attempt N (before attempt N+1) is
+	// min(MaxDelay, BaseDelay*2^(N-1)) plus a random amount up to Jitter. BaseDelay and MaxDelay default to 100ms
+	// and 2s. Jitter, like RetryPolicy.Jitter, is not defaulted: <= 0 means
+	// no jitter rather than "unset", so pass 250*time.Millisecond
+	// explicitly for the old default behavior.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Jitter    time.Duration
+}
+
+// withDefaults returns a copy of opts with zero-valued fields replaced by
+// their defaults.
+func (opts BulkOptions) withDefaults() BulkOptions {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 100 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 2 * time.Second
+	}
+	// Jitter is deliberately not defaulted here: like
+	// rest_client.RetryPolicy.Jitter, 0 means "no jitter" rather than
+	// "unset", so an explicit BulkOptions{Jitter: 0} disables it instead of
+	// silently getting the default back.
+	return opts
+}
+
+// backoff computes the delay after the given attempt number (1-indexed) has
+// failed, before the next attempt.
+func (opts BulkOptions) backoff(attempt int) time.Duration {
+	delay := time.Duration(float64(opts.BaseDelay) * math.Pow(2, float64(attempt-1)))
+	if delay > opts.MaxDelay {
+		delay = opts.MaxDelay
+	}
+	if opts.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(opts.Jitter)))
+	}
+	return delay
+}
+
+// BulkResult carries the outcome of a bulk operation: every key that
+// succeeded, and an error for every key that didn't, so callers get precise
+// partial-failure information instead of an all-or-nothing result.
+type BulkResult struct {
+	// Succeeded holds one entry per key that completed successfully. For
+	// DeleteVariables, only Key is populated on each entry.
+	Succeeded []EnvVariable
+
+	// Errors maps each failed key to the error from its last attempt. Keys
+	// that run after StopOnError canceled the batch are recorded with
+	// whatever error fn returns for the canceled context (context.Canceled,
+	// possibly wrapped).
+	Errors map[string]error
+}
+
+// runBulk executes fn for each key in keys across a pool of opts.Concurrency
+// workers, retrying a failing key up to opts.MaxAttempts times with backoff
+// before recording its error. If opts.StopOnError is set, the first failure
+// (after its retries are exhausted) cancels the context shared by every call
+// to fn, including calls already in flight.
+func runBulk(ctx context.Context, keys []string, opts BulkOptions, fn func(ctx context.Context, key string) (EnvVariable, error)) BulkResult {
+	opts = opts.withDefaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		result = BulkResult{Errors: make(map[string]error)}
+		sem    = make(chan struct{}, opts.Concurrency)
+		wg     sync.WaitGroup
+	)
+
+	for _, key := range keys {
+		key := key
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var (
+				variable EnvVariable
+				err      error
+			)
+			for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+				variable, err = fn(ctx, key)
+				if err == nil {
+					break
+				}
+				if attempt == opts.MaxAttempts || ctx.Err() != nil {
+					break
+				}
+				select {
+				case <-time.After(opts.backoff(attempt)):
+				case <-ctx.Done():
+				}
+			}
+
+			mu.Lock()
+			if err != nil {
+				result.Errors[key] = err
+				if opts.StopOnError {
+					cancel()
+				}
+			} else {
+				result.Succeeded = append(result.Succeeded, variable)
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return result
+}
+
+// SetVariables creates or updates many variables in an environment at once,
+// fanning the calls out across a bounded worker pool instead of the
+// all-or-nothing behavior of ImportEnvFile.
+//
+// Usage example:
+//
+//	result, err := client.SetVariables(context.Background(), "env_123abc", []env_client.EnvVariableRequest{
+//	    {Key: "API_KEY", Value: "secret", IsMasked: true},
+//	    {Key: "DEBUG", Value: "true"},
+//	}, env_client.BulkOptions{Concurrency: 4})
+//	if err != nil {
+//	    log.Fatalf("Failed to set variables: %v", err)
+//	}
+//	for key, err := range result.Errors {
+//	    log.Printf("%s failed: %v", key, err)
+//	}
+//
+// Parameters:
+//   - ctx: Context for request cancellation/timeouts
+//   - envID: Environment identifier
+//   - vars: Variables to create or update
+//   - opts: Concurrency, retry, and stop-on-error behavior
+//
+// Returns:
+//   - BulkResult: Per-key successes and errors
+//   - error: Non-nil only if ctx was already canceled before any work started
+func (api *EnvClient) SetVariables(ctx context.Context, envID string, vars []EnvVariableRequest, opts BulkOptions) (BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return BulkResult{}, err
+	}
+
+	byKey := make(map[string]*EnvVariableRequest, len(vars))
+	keys := make([]string, len(vars))
+	for i := range vars {
+		byKey[vars[i].Key] = &vars[i]
+		keys[i] = vars[i].Key
+	}
+
+	result := runBulk(ctx, keys, opts, func(ctx context.Context, key string) (EnvVariable, error) {
+		variable, err := api.SetVariable(ctx, envID, byKey[key])
+		if err != nil {
+			return EnvVariable{}, err
+		}
+		return *variable, nil
+	})
+	return result, nil
+}
+
+// DeleteVariables removes many variables from an environment at once,
+// fanning the calls out across a bounded worker pool.
+//
+// Parameters:
+//   - ctx: Context for request cancellation/timeouts
+//   - envID: Environment identifier
+//   - keys: Variable keys to delete
+//   - opts: Concurrency, retry, and stop-on-error behavior
+//
+// Returns:
+//   - BulkResult: Per-key successes (Key populated, nothing else) and errors
+//   - error: Non-nil only if ctx was already canceled before any work started
+func (api *EnvClient) DeleteVariables(ctx context.Context, envID string, keys []string, opts BulkOptions) (BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return BulkResult{}, err
+	}
+
+	result := runBulk(ctx, keys, opts, func(ctx context.Context, key string) (EnvVariable, error) {
+		if err := api.DeleteVariable(ctx, envID, key); err != nil {
+			return EnvVariable{}, err
+		}
+		return EnvVariable{Key: key}, nil
+	})
+	return result, nil
+}
+
+// GetVariables retrieves many variables from an environment at once, fanning
+// the calls out across a bounded worker pool.
+//
+// Parameters:
+//   - ctx: Context for request cancellation/timeouts
+//   - envID: Environment identifier
+//   - keys: Variable keys to fetch
+//   - opts: Concurrency, retry, and stop-on-error behavior
+//
+// Returns:
+//   - BulkResult: Per-key successes and errors
+//   - error: Non-nil only if ctx was already canceled before any work started
+func (api *EnvClient) GetVariables(ctx context.Context, envID string, keys []string, opts BulkOptions) (BulkResult, error) {
+	if err := ctx.Err(); err != nil {
+		return BulkResult{}, err
+	}
+
+	result := runBulk(ctx, keys, opts, func(ctx context.Context, key string) (EnvVariable, error) {
+		variable, err := api.GetVariable(ctx, envID, key)
+		if err != nil {
+			return EnvVariable{}, err
+		}
+		return *variable, nil
+	})
+	return result, nil
+}
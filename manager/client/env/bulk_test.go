@@ -0,0 +1,101 @@
+package env_client
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBulkAllSucceed(t *testing.T) {
+	keys := []string{"A", "B", "C"}
+	result := runBulk(context.Background(), keys, BulkOptions{}, func(_ context.Context, key string) (EnvVariable, error) {
+		return EnvVariable{Key: key, Value: "ok"}, nil
+	})
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	if len(result.Succeeded) != len(keys) {
+		t.Fatalf("got %d successes, want %d", len(result.Succeeded), len(keys))
+	}
+}
+
+func TestRunBulkPartialFailureReportsBothSides(t *testing.T) {
+	keys := []string{"GOOD", "BAD"}
+	opts := BulkOptions{MaxAttempts: 1}
+
+	result := runBulk(context.Background(), keys, opts, func(_ context.Context, key string) (EnvVariable, error) {
+		if key == "BAD" {
+			return EnvVariable{}, fmt.Errorf("boom")
+		}
+		return EnvVariable{Key: key}, nil
+	})
+
+	if len(result.Succeeded) != 1 || result.Succeeded[0].Key != "GOOD" {
+		t.Errorf("Succeeded = %+v, want just GOOD", result.Succeeded)
+	}
+	if err := result.Errors["BAD"]; err == nil || err.Error() != "boom" {
+		t.Errorf("Errors[BAD] = %v, want \"boom\"", err)
+	}
+}
+
+func TestRunBulkRetriesBeforeRecordingError(t *testing.T) {
+	var attempts int64
+	opts := BulkOptions{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Jitter: time.Millisecond}
+
+	result := runBulk(context.Background(), []string{"KEY"}, opts, func(_ context.Context, key string) (EnvVariable, error) {
+		n := atomic.AddInt64(&attempts, 1)
+		if n < 3 {
+			return EnvVariable{}, fmt.Errorf("attempt %d failed", n)
+		}
+		return EnvVariable{Key: key}, nil
+	})
+
+	if atomic.LoadInt64(&attempts) != 3 {
+		t.Errorf("fn called %d times, want 3", attempts)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("unexpected errors after eventual success: %v", result.Errors)
+	}
+	if len(result.Succeeded) != 1 {
+		t.Errorf("got %d successes, want 1", len(result.Succeeded))
+	}
+}
+
+func TestRunBulkStopOnErrorCancelsRemainingWork(t *testing.T) {
+	keys := []string{"BAD", "NEVER-STARTS"}
+	opts := BulkOptions{Concurrency: 1, MaxAttempts: 1, StopOnError: true}
+
+	var neverStartsRan int64
+	result := runBulk(context.Background(), keys, opts, func(ctx context.Context, key string) (EnvVariable, error) {
+		if key == "BAD" {
+			return EnvVariable{}, fmt.Errorf("boom")
+		}
+		if ctx.Err() != nil {
+			return EnvVariable{}, ctx.Err()
+		}
+		atomic.AddInt64(&neverStartsRan, 1)
+		return EnvVariable{Key: key}, nil
+	})
+
+	if len(result.Succeeded) != 0 {
+		t.Errorf("Succeeded = %+v, want none once StopOnError cancels the batch", result.Succeeded)
+	}
+	if _, ok := result.Errors["BAD"]; !ok {
+		t.Error("expected BAD's own failure to be recorded")
+	}
+	if _, ok := result.Errors["NEVER-STARTS"]; !ok {
+		t.Error("expected NEVER-STARTS to be recorded as canceled")
+	}
+}
+
+func TestBulkOptionsBackoffCapsAtMaxDelay(t *testing.T) {
+	opts := BulkOptions{BaseDelay: time.Second, MaxDelay: 2 * time.Second, Jitter: 0}.withDefaults()
+
+	delay := opts.backoff(10) // 2^9 base delays would blow way past MaxDelay.
+	if delay != opts.MaxDelay {
+		t.Errorf("backoff(10) = %v, want capped at MaxDelay %v", delay, opts.MaxDelay)
+	}
+}
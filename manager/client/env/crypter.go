@@ -0,0 +1,191 @@
+// crypter.go
+// Optional client-side envelope encryption for masked variables. When
+// Options.Crypter is set, SetVariable encrypts an IsMasked value before it
+// ever reaches the API, and GetVariable/ListVariables/ExportEnvFile
+// transparently decrypt it on the way back, so the DataForge server (and
+// anyone with API access alone) only ever sees ciphertext.
+
+package env_client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// dfencSentinel marks a variable value as a Crypter envelope rather than
+// plaintext, so values written before client-side encryption was enabled
+// (or by another client that never enabled it) still round-trip unchanged.
+const dfencSentinel = "dfenc:v1:"
+
+// dfencVersion1 is the only envelope format Crypter currently produces.
+const dfencVersion1 byte = 1
+
+// Crypter seals and opens masked variable values for an EnvClient. aad ties
+// a ciphertext to the envID and key it belongs to, so a value can't be
+// replayed under a different variable without Open failing.
+type Crypter interface {
+	// Seal encrypts plaintext, authenticating aad alongside it.
+	Seal(plaintext []byte, aad []byte) (ciphertext []byte, err error)
+
+	// Open decrypts ciphertext produced by Seal with the same aad.
+	Open(ciphertext []byte, aad []byte) ([]byte, error)
+}
+
+// aesGCMCrypter is the default Crypter. It derives a one-off AES-256 key per
+// Seal/Open call from masterKey via HKDF-SHA256, using aad as HKDF's info
+// parameter, so every envID/key pair is sealed under its own key without
+// masterKey itself ever touching AES directly. Ciphertexts are
+// [version byte][12-byte nonce][AES-GCM output].
+type aesGCMCrypter struct {
+	masterKey []byte
+}
+
+// NewAESGCMCrypter creates a Crypter that derives per-variable keys from
+// masterKey. masterKey should be at least 32 bytes of high-entropy material
+// (e.g. generated with crypto/rand) and kept outside of version control;
+// losing it makes every value ever sealed with it unrecoverable.
+func NewAESGCMCrypter(masterKey []byte) (Crypter, error) {
+	if len(masterKey) == 0 {
+		return nil, fmt.Errorf("dfenc: master key must not be empty")
+	}
+	return &aesGCMCrypter{masterKey: masterKey}, nil
+}
+
+func (c *aesGCMCrypter) deriveKey(aad []byte) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, c.masterKey, nil, aad), key); err != nil {
+		return nil, fmt.Errorf("dfenc: failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+func (c *aesGCMCrypter) Seal(plaintext, aad []byte) ([]byte, error) {
+	key, err := c.deriveKey(aad)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("dfenc: failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, 1+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, dfencVersion1)
+	out = append(out, nonce...)
+	out = gcm.Seal(out, nonce, plaintext, aad)
+	return out, nil
+}
+
+func (c *aesGCMCrypter) Open(ciphertext, aad []byte) ([]byte, error) {
+	key, err := c.deriveKey(aad)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < 1+gcm.NonceSize() {
+		return nil, fmt.Errorf("dfenc: ciphertext too short")
+	}
+	if version := ciphertext[0]; version != dfencVersion1 {
+		return nil, fmt.Errorf("dfenc: unsupported envelope version %d", version)
+	}
+	nonce := ciphertext[1 : 1+gcm.NonceSize()]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[1+gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("dfenc: failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("dfenc: failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("dfenc: failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// encryptMaskedValue encrypts value for envID/key with api.crypter and
+// returns the dfenc:v1: envelope SetVariable sends the server in its place.
+// value is returned unchanged if no Crypter is configured.
+func (api *EnvClient) encryptMaskedValue(envID, key, value string) (string, error) {
+	if api.crypter == nil {
+		return value, nil
+	}
+	ciphertext, err := api.crypter.Seal([]byte(value), dfencAAD(envID, key))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt masked variable %q: %w", key, err)
+	}
+	return dfencSentinel + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptMaskedValue reverses encryptMaskedValue. value is returned
+// unchanged if no Crypter is configured, or if it doesn't carry the
+// dfenc:v1: sentinel, which covers both unmasked variables and masked ones
+// written before client-side encryption was ever enabled.
+func (api *EnvClient) decryptMaskedValue(envID, key, value string) (string, error) {
+	if api.crypter == nil || !strings.HasPrefix(value, dfencSentinel) {
+		return value, nil
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, dfencSentinel))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted masked variable %q: %w", key, err)
+	}
+	plaintext, err := api.crypter.Open(ciphertext, dfencAAD(envID, key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt masked variable %q: %w", key, err)
+	}
+	return string(plaintext), nil
+}
+
+// decryptEnvFileContent decrypts every dfenc:v1: value in a .env-formatted
+// export, leaving lines without the sentinel (comments, blank lines,
+// unmasked variables, and masked ones predating client-side encryption)
+// untouched.
+func (api *EnvClient) decryptEnvFileContent(envID, content string) (string, error) {
+	if api.crypter == nil {
+		return content, nil
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || !strings.Contains(parts[1], dfencSentinel) {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		decrypted, err := api.decryptMaskedValue(envID, key, strings.TrimSpace(parts[1]))
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt exported variable %q: %w", key, err)
+		}
+		lines[i] = key + "=" + decrypted
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// dfencAAD builds the associated data a value is sealed/opened under, so a
+// ciphertext can't be replayed under a different environment or key name.
+func dfencAAD(envID, key string) []byte {
+	return []byte(envID + "|" + key)
+}
@@ -0,0 +1,122 @@
+package env_client
+
+import "testing"
+
+func TestAESGCMCrypterSealOpenRoundTrip(t *testing.T) {
+	crypter, err := NewAESGCMCrypter([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewAESGCMCrypter: %v", err)
+	}
+
+	aad := []byte("env_123|DB_PASSWORD")
+	ciphertext, err := crypter.Seal([]byte("hunter2"), aad)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	plaintext, err := crypter.Open(ciphertext, aad)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(plaintext) != "hunter2" {
+		t.Errorf("Open returned %q, want %q", plaintext, "hunter2")
+	}
+}
+
+func TestAESGCMCrypterOpenRejectsMismatchedAAD(t *testing.T) {
+	crypter, err := NewAESGCMCrypter([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewAESGCMCrypter: %v", err)
+	}
+
+	ciphertext, err := crypter.Seal([]byte("hunter2"), []byte("env_123|DB_PASSWORD"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if _, err := crypter.Open(ciphertext, []byte("env_456|DB_PASSWORD")); err == nil {
+		t.Error("Open with a different envID succeeded; want an authentication failure")
+	}
+}
+
+func TestNewAESGCMCrypterRejectsEmptyKey(t *testing.T) {
+	if _, err := NewAESGCMCrypter(nil); err == nil {
+		t.Error("NewAESGCMCrypter(nil) succeeded; want an error")
+	}
+}
+
+func TestEncryptDecryptMaskedValueRoundTrip(t *testing.T) {
+	crypter, err := NewAESGCMCrypter([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewAESGCMCrypter: %v", err)
+	}
+	api := &EnvClient{crypter: crypter}
+
+	envelope, err := api.encryptMaskedValue("env_123", "DB_PASSWORD", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptMaskedValue: %v", err)
+	}
+	if envelope == "hunter2" {
+		t.Fatal("encryptMaskedValue returned the plaintext unchanged")
+	}
+
+	plaintext, err := api.decryptMaskedValue("env_123", "DB_PASSWORD", envelope)
+	if err != nil {
+		t.Fatalf("decryptMaskedValue: %v", err)
+	}
+	if plaintext != "hunter2" {
+		t.Errorf("decryptMaskedValue returned %q, want %q", plaintext, "hunter2")
+	}
+}
+
+func TestEncryptMaskedValueNoopWithoutCrypter(t *testing.T) {
+	api := &EnvClient{}
+
+	value, err := api.encryptMaskedValue("env_123", "DB_PASSWORD", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptMaskedValue: %v", err)
+	}
+	if value != "hunter2" {
+		t.Errorf("encryptMaskedValue returned %q without a crypter configured, want it unchanged", value)
+	}
+}
+
+func TestDecryptMaskedValueIgnoresValuesWithoutSentinel(t *testing.T) {
+	crypter, err := NewAESGCMCrypter([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewAESGCMCrypter: %v", err)
+	}
+	api := &EnvClient{crypter: crypter}
+
+	plaintext, err := api.decryptMaskedValue("env_123", "DB_PASSWORD", "plain-value")
+	if err != nil {
+		t.Fatalf("decryptMaskedValue: %v", err)
+	}
+	if plaintext != "plain-value" {
+		t.Errorf("decryptMaskedValue returned %q for an unsealed value, want it unchanged", plaintext)
+	}
+}
+
+func TestDecryptEnvFileContentOnlyTouchesSealedLines(t *testing.T) {
+	crypter, err := NewAESGCMCrypter([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewAESGCMCrypter: %v", err)
+	}
+	api := &EnvClient{crypter: crypter}
+
+	envelope, err := api.encryptMaskedValue("env_123", "DB_PASSWORD", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptMaskedValue: %v", err)
+	}
+
+	content := "# comment\nAPP_NAME=dataforge\nDB_PASSWORD=" + envelope
+	decrypted, err := api.decryptEnvFileContent("env_123", content)
+	if err != nil {
+		t.Fatalf("decryptEnvFileContent: %v", err)
+	}
+
+	want := "# comment\nAPP_NAME=dataforge\nDB_PASSWORD=hunter2"
+	if decrypted != want {
+		t.Errorf("decryptEnvFileContent returned %q, want %q", decrypted, want)
+	}
+}
@@ -2,14 +2,15 @@ package env_client
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"strings"
 	"time"
 
-	// "github.com/your-org/rest_client" // Adjust import path as needed
+	rest_client "dataforge/client"
 )
 
 // | `GET`       | `/envs/{env_id}`                          | Get environment details                     | `EnvironmentService`          |
@@ -24,7 +25,10 @@ import (
 // EnvClient is a specialized client for interacting with the Environment Management API.
 // It wraps the underlying REST client and provides domain-specific methods.
 type EnvClient struct {
-	client *rest_client.Client // Underlying REST client for HTTP operations
+	client       *rest_client.Client // Underlying REST client for HTTP operations
+	shareBackend ShareBackend        // Where UploadObfuscatedEnvFile/DownloadFromLink store and serve payloads
+	crypter      Crypter             // Optional client-side encryption for masked variable values; nil disables it
+	progressFunc func(bytesDone, bytesTotal int64) // Optional progress hook for ImportEnvStream/ExportEnvStream; nil disables it
 }
 
 // Environment represents an environment in the system
@@ -59,7 +63,7 @@ type ListVariablesOptions struct {
 
 // ImportEnvRequest represents a request to import variables from .env format
 type ImportEnvRequest struct {
-	Content          string `json:"content"`
+	Content           string `json:"content"`
 	OverwriteExisting bool   `json:"overwrite_existing,omitempty"`
 }
 
@@ -138,9 +142,16 @@ func NewEnvClient(baseURL, apiKey string, options *Options) *EnvClient {
 		restClient = rest_client.NewClient(rest_client.WithHeader(k, v))
 	}
 
-	return &EnvClient{
-		client: restClient,
+	api := &EnvClient{
+		client:       restClient,
+		shareBackend: options.ShareBackend,
+		crypter:      options.Crypter,
+		progressFunc: options.ProgressFunc,
 	}
+	if api.shareBackend == nil {
+		api.shareBackend = newRestShareBackend(api)
+	}
+	return api
 }
 
 // Options contains configuration options for the EnvClient client
@@ -148,6 +159,28 @@ type Options struct {
 	APIKeyHeaderName string            // Header name for API key (default: "Authorization")
 	Timeout          time.Duration     // Request timeout (default: 30s)
 	Headers          map[string]string // Additional headers to include in all requests
+
+	// ShareBackend is where UploadObfuscatedEnvFile and DownloadFromLink
+	// store and serve their payload. Left nil, the client keeps it on the
+	// DataForge server itself; set it to env_client.NewS3ShareBackend,
+	// NewAzureBlobShareBackend, NewGCSShareBackend, or a backend built via
+	// NewShareBackend/RegisterShareBackend, to keep it on your own object
+	// store instead. GenerateShareLink does not use it: it always asks the
+	// DataForge server for the link.
+	ShareBackend ShareBackend
+
+	// Crypter, if set, makes SetVariable encrypt an IsMasked value locally
+	// before it ever reaches the API, and GetVariable/ListVariables/
+	// ExportEnvFile transparently decrypt it on the way back. Leave nil to
+	// send masked values to the server as plaintext, the pre-existing
+	// behavior. NewAESGCMCrypter builds the default implementation.
+	Crypter Crypter
+
+	// ProgressFunc, if set, is called as bytes move during
+	// ImportEnvStream/ExportEnvStream, with bytesTotal 0 meaning the total
+	// size isn't known up front. NewTerminalProgressFunc builds one that
+	// renders a live cheggaaa/pb bar when os.Stderr is a terminal.
+	ProgressFunc func(bytesDone, bytesTotal int64)
 }
 
 // ----- Environment Operations -----
@@ -224,6 +257,9 @@ func (api *EnvClient) DeleteEnvironment(ctx context.Context, envID string) error
 
 // SetVariable creates or updates an environment variable.
 //
+// If variable.IsMasked is true and Options.Crypter was set on the client,
+// Value is encrypted locally before this method ever sends it to the API.
+//
 // Usage example:
 //
 //	// Create a regular variable
@@ -250,8 +286,19 @@ func (api *EnvClient) DeleteEnvironment(ctx context.Context, envID string) error
 //   - error: Any error encountered during the operation
 func (api *EnvClient) SetVariable(ctx context.Context, envID string, variable *EnvVariableRequest) (*EnvVariable, error) {
 	path := fmt.Sprintf("/envs/%s/variables/", envID)
-	
-	resp, err := api.client.Post(ctx, path, variable, nil)
+
+	toSend := variable
+	if variable.IsMasked {
+		encrypted, err := api.encryptMaskedValue(envID, variable.Key, variable.Value)
+		if err != nil {
+			return nil, err
+		}
+		sealed := *variable
+		sealed.Value = encrypted
+		toSend = &sealed
+	}
+
+	resp, err := api.client.Post(ctx, path, toSend, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set variable: %w", err)
 	}
@@ -271,7 +318,9 @@ func (api *EnvClient) SetVariable(ctx context.Context, envID string, variable *E
 // ListVariables retrieves all variables in an environment.
 //
 // By default, masked variable values are hidden. Set includeMaskedValues to true
-// to retrieve the actual values of masked variables.
+// to retrieve the actual values of masked variables. Values that were
+// encrypted locally via Options.Crypter are transparently decrypted before
+// being returned.
 //
 // Usage example:
 //
@@ -316,12 +365,26 @@ func (api *EnvClient) ListVariables(ctx context.Context, envID string, includeMa
 	if err := resp.Decode(&variables); err != nil {
 		return nil, fmt.Errorf("failed to decode variables: %w", err)
 	}
-	
+
+	for i := range variables {
+		if !variables[i].IsMasked {
+			continue
+		}
+		decrypted, err := api.decryptMaskedValue(envID, variables[i].Key, variables[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		variables[i].Value = decrypted
+	}
+
 	return variables, nil
 }
 
 // GetVariable retrieves a specific variable by key.
 //
+// If the variable is masked and was encrypted locally via Options.Crypter,
+// its value is transparently decrypted before being returned.
+//
 // Usage example:
 //
 //	variable, err := client.GetVariable(context.Background(), "env_123abc", "DATABASE_URL")
@@ -354,7 +417,15 @@ func (api *EnvClient) GetVariable(ctx context.Context, envID, key string) (*EnvV
 	if err := resp.Decode(&variable); err != nil {
 		return nil, fmt.Errorf("failed to decode variable: %w", err)
 	}
-	
+
+	if variable.IsMasked {
+		decrypted, err := api.decryptMaskedValue(envID, variable.Key, variable.Value)
+		if err != nil {
+			return nil, err
+		}
+		variable.Value = decrypted
+	}
+
 	return &variable, nil
 }
 
@@ -392,8 +463,11 @@ func (api *EnvClient) DeleteVariable(ctx context.Context, envID, key string) err
 
 // ImportEnvFile imports variables from a .env file format.
 //
-// This method accepts a string in standard .env file format and creates 
-// or updates variables in the specified environment.
+// This method accepts a string in standard .env file format and creates
+// or updates variables in the specified environment. Large imports
+// (thousands of variables, multi-megabyte files) should use ImportEnvStream
+// instead, which streams to a separate endpoint instead of buffering the
+// whole request body into memory first.
 //
 // Usage example:
 //
@@ -402,7 +476,7 @@ func (api *EnvClient) DeleteVariable(ctx context.Context, envID, key string) err
 //	API_KEY=secret-key
 //	DEBUG=true
 //	`
-//	
+//
 //	err := client.ImportEnvFile(context.Background(), "env_123abc", envContent, true)
 //	if err != nil {
 //	    log.Fatalf("Failed to import variables: %v", err)
@@ -418,36 +492,42 @@ func (api *EnvClient) DeleteVariable(ctx context.Context, envID, key string) err
 //   - error: Any error encountered during the import
 func (api *EnvClient) ImportEnvFile(ctx context.Context, envID, content string, overwrite bool) error {
 	path := fmt.Sprintf("/envs/%s/import/", envID)
-	
+
 	req := ImportEnvRequest{
 		Content:           content,
 		OverwriteExisting: overwrite,
 	}
-	
+
 	resp, err := api.client.Post(ctx, path, req, nil)
 	if err != nil {
 		return fmt.Errorf("failed to import .env: %w", err)
 	}
-	
+
 	if !resp.IsSuccess() {
 		return fmt.Errorf("API error: %d - %s", resp.StatusCode, string(resp.Body))
 	}
-	
+
 	return nil
 }
 
 // ExportEnvFile exports environment variables in .env file format.
 //
+// Values that were encrypted locally via Options.Crypter are transparently
+// decrypted before being returned; lines without the dfenc:v1: sentinel
+// pass through unchanged. Large exports should use ExportEnvStream instead,
+// which streams from a separate endpoint instead of buffering the whole
+// response body into memory first.
+//
 // Usage example:
 //
 //	content, err := client.ExportEnvFile(context.Background(), "env_123abc")
 //	if err != nil {
 //	    log.Fatalf("Failed to export variables: %v", err)
 //	}
-//	
+//
 //	fmt.Println("Exported .env file:")
 //	fmt.Println(content)
-//	
+//
 //	// Save to file
 //	err = os.WriteFile(".env", []byte(content), 0644)
 //	if err != nil {
@@ -463,18 +543,18 @@ func (api *EnvClient) ImportEnvFile(ctx context.Context, envID, content string,
 //   - error: Any error encountered during the export
 func (api *EnvClient) ExportEnvFile(ctx context.Context, envID string) (string, error) {
 	path := fmt.Sprintf("/envs/%s/export/", envID)
-	
+
 	resp, err := api.client.Get(ctx, path, nil, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to export .env: %w", err)
 	}
-	
+
 	if !resp.IsSuccess() {
 		return "", fmt.Errorf("API error: %d - %s", resp.StatusCode, string(resp.Body))
 	}
-	
+
 	// The response body contains the .env file content as plain text
-	return string(resp.Body), nil
+	return api.decryptEnvFileContent(envID, string(resp.Body))
 }
 
 // GenerateShareLink creates a one-time download link for an environment.
@@ -523,10 +603,10 @@ func (api *EnvClient) GenerateShareLink(ctx context.Context, envID string) (*Sha
 
 // UploadObfuscatedEnvFile uploads an obfuscated .env file and returns a download link.
 //
-// This is a higher-level method that combines multiple API operations to:
-// 1. Read an obfuscated .env file
-// 2. Upload it to a specific environment
-// 3. Generate and return a one-time download link
+// This is a higher-level method that stores fileContent with the client's
+// ShareBackend (the DataForge server itself, unless Options.ShareBackend
+// was set to an S3/Azure Blob/GCS backend) and returns the URL it hands
+// back for later retrieval.
 //
 // Usage example:
 //
@@ -557,25 +637,18 @@ func (api *EnvClient) GenerateShareLink(ctx context.Context, envID string) (*Sha
 //   - string: URL where the file can be downloaded
 //   - error: Any error encountered during the process
 func (api *EnvClient) UploadObfuscatedEnvFile(ctx context.Context, envID, fileContent string) (string, error) {
-	// Step 1: Import the .env file
-	err := api.ImportEnvFile(ctx, envID, fileContent, true)
+	url, err := api.shareBackend.Put(ctx, envID, []byte(fileContent), 0)
 	if err != nil {
-		return "", fmt.Errorf("failed to import obfuscated env file: %w", err)
+		return "", fmt.Errorf("failed to upload obfuscated env file: %w", err)
 	}
-	
-	// Step 2: Generate a download link
-	shareLink, err := api.GenerateShareLink(ctx, envID)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate download link: %w", err)
-	}
-	
-	return shareLink.URL, nil
+	return url, nil
 }
 
 // DownloadFromLink downloads content from a generated share link.
 //
 // This method retrieves the content from a URL previously generated by
-// GenerateShareLink or UploadObfuscatedEnvFile.
+// GenerateShareLink or UploadObfuscatedEnvFile, via the client's
+// ShareBackend.
 //
 // Usage example:
 //
@@ -599,31 +672,10 @@ func (api *EnvClient) UploadObfuscatedEnvFile(ctx context.Context, envID, fileCo
 //   - string: Content retrieved from the URL
 //   - error: Any error encountered during download
 func (api *EnvClient) DownloadFromLink(ctx context.Context, url string) (string, error) {
-	// Create a new request directly to the URL
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	
-	// Use the HTTP client from the underlying REST client
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("download request failed: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(body))
-	}
-	
-	// Read the content
-	content, err := io.ReadAll(resp.Body)
+	content, err := api.shareBackend.Get(ctx, url)
 	if err != nil {
-		return "", fmt.Errorf("failed to read download content: %w", err)
+		return "", fmt.Errorf("failed to download content: %w", err)
 	}
-	
 	return string(content), nil
 }
 
@@ -698,6 +750,201 @@ func (api *EnvClient) LoadEnvFromFile(ctx context.Context, envID, filePath strin
 	if err != nil {
 		return fmt.Errorf("failed to import variables: %w", err)
 	}
-	
+
 	return nil
+}
+
+// ----- GitHub Actions Integration -----
+
+// ghActionsPathKey is the variable key treated specially by
+// ExportToGitHubActions: its value is split on the OS path-list separator
+// and written to $GITHUB_PATH instead of $GITHUB_ENV/$GITHUB_OUTPUT, so a
+// variable named PATH prepends directories to later steps' PATH rather than
+// becoming a literal PATH=... environment variable.
+const ghActionsPathKey = "PATH"
+
+// heredocDelimiterAttempts bounds how many times randomHeredocDelimiter
+// retries before giving up, in the vanishingly unlikely case a 16-byte
+// random delimiter collides with the value it's meant to wrap.
+const heredocDelimiterAttempts = 10
+
+// ExportToGitHubActions reads every variable in envID via ListVariables and
+// emits the workflow commands a GitHub Actions runner reads to make them
+// available to later steps: each becomes a line in the files pointed to by
+// $GITHUB_ENV (environment variable) and $GITHUB_OUTPUT (step output), or,
+// for a variable named "PATH", one $GITHUB_PATH line per directory. Every
+// variable with IsMasked == true is also masked via EmitMask before any of
+// its values are written, so it never appears unredacted in the job log.
+//
+// Usage example:
+//
+//	err := client.ExportToGitHubActions(context.Background(), "env_123abc")
+//	if err != nil {
+//	    log.Fatalf("Failed to export to GitHub Actions: %v", err)
+//	}
+//
+// Parameters:
+//   - ctx: Context for request cancellation/timeouts
+//   - envID: Environment identifier
+//
+// Returns:
+//   - error: Any error encountered listing variables or writing the workflow command files
+func (api *EnvClient) ExportToGitHubActions(ctx context.Context, envID string) error {
+	variables, err := api.ListVariables(ctx, envID, true)
+	if err != nil {
+		return fmt.Errorf("failed to list variables for GitHub Actions export: %w", err)
+	}
+
+	for _, v := range variables {
+		if v.IsMasked {
+			api.EmitMask(os.Stdout, v.Value)
+		}
+	}
+
+	var envVars, pathVars []EnvVariable
+	for _, v := range variables {
+		if strings.EqualFold(v.Key, ghActionsPathKey) {
+			pathVars = append(pathVars, v)
+			continue
+		}
+		envVars = append(envVars, v)
+	}
+
+	if err := api.appendGitHubActionsAssignments(os.Getenv("GITHUB_ENV"), envVars); err != nil {
+		return fmt.Errorf("failed to write $GITHUB_ENV: %w", err)
+	}
+	if err := api.appendGitHubActionsAssignments(os.Getenv("GITHUB_OUTPUT"), envVars); err != nil {
+		return fmt.Errorf("failed to write $GITHUB_OUTPUT: %w", err)
+	}
+	if err := api.appendGitHubActionsPath(os.Getenv("GITHUB_PATH"), pathVars); err != nil {
+		return fmt.Errorf("failed to write $GITHUB_PATH: %w", err)
+	}
+	return nil
+}
+
+// appendGitHubActionsAssignments appends each variable to path as a
+// KEY=VALUE (or heredoc, for multi-line values) workflow command. path is
+// normally $GITHUB_ENV or $GITHUB_OUTPUT; an empty path (not running in
+// GitHub Actions) is a no-op.
+func (api *EnvClient) appendGitHubActionsAssignments(path string, variables []EnvVariable) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	for _, v := range variables {
+		if err := writeGitHubActionsAssignment(f, v.Key, v.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendGitHubActionsPath appends each directory in every variable's value
+// (split on the OS path-list separator) to path as its own line. path is
+// normally $GITHUB_PATH; an empty path is a no-op.
+func (api *EnvClient) appendGitHubActionsPath(path string, variables []EnvVariable) error {
+	if path == "" || len(variables) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	for _, v := range variables {
+		for _, dir := range strings.Split(v.Value, string(os.PathListSeparator)) {
+			if dir == "" {
+				continue
+			}
+			if _, err := fmt.Fprintln(f, dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeGitHubActionsAssignment writes key=value to w as a single-line
+// workflow command, or, if value contains a newline, as the multi-line
+// heredoc form ("KEY<<DELIM\nvalue\nDELIM\n") GitHub Actions requires for
+// values that can't be represented on one line.
+func writeGitHubActionsAssignment(w io.Writer, key, value string) error {
+	if !strings.ContainsAny(value, "\r\n") {
+		_, err := fmt.Fprintf(w, "%s=%s\n", key, value)
+		return err
+	}
+
+	delimiter, err := randomHeredocDelimiter(value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", key, delimiter, value, delimiter)
+	return err
+}
+
+// randomHeredocDelimiter generates a random delimiter for the heredoc form
+// of a workflow command assignment, verifying it doesn't appear inside
+// value (which would truncate it) and regenerating if it does.
+func randomHeredocDelimiter(value string) (string, error) {
+	for i := 0; i < heredocDelimiterAttempts; i++ {
+		raw := make([]byte, 16)
+		if _, err := rand.Read(raw); err != nil {
+			return "", fmt.Errorf("failed to generate heredoc delimiter: %w", err)
+		}
+
+		delimiter := "ghadelim_" + hex.EncodeToString(raw)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a heredoc delimiter absent from the value after %d attempts", heredocDelimiterAttempts)
+}
+
+// escapeWorkflowCommandValue escapes value per GitHub's workflow-command
+// data-escaping rules (% first, then CR, then LF) so it can't terminate the
+// command early or be parsed as a forged command of its own when embedded
+// in a "::command::value" line.
+func escapeWorkflowCommandValue(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	value = strings.ReplaceAll(value, "\n", "%0A")
+	return value
+}
+
+// EmitMask writes a GitHub Actions "::add-mask::" workflow command for
+// value to w, so the runner redacts it from any log output from this point
+// on. Call it before printing or exporting a sensitive value.
+//
+// Usage example:
+//
+//	client.EmitMask(os.Stdout, secretValue)
+func (api *EnvClient) EmitMask(w io.Writer, value string) {
+	fmt.Fprintf(w, "::add-mask::%s\n", escapeWorkflowCommandValue(value))
+}
+
+// EmitGroup writes a GitHub Actions "::group::" workflow command, starting
+// a collapsible log section titled name. Pair it with a matching EndGroup
+// call once the section's steps are done.
+//
+// Usage example:
+//
+//	client.EmitGroup(os.Stdout, "Exporting secrets")
+//	// ... steps ...
+//	client.EndGroup(os.Stdout)
+func (api *EnvClient) EmitGroup(w io.Writer, name string) {
+	fmt.Fprintf(w, "::group::%s\n", escapeWorkflowCommandValue(name))
+}
+
+// EndGroup writes the "::endgroup::" workflow command that closes the
+// section started by the most recent EmitGroup call.
+func (api *EnvClient) EndGroup(w io.Writer) {
+	fmt.Fprintln(w, "::endgroup::")
 }
\ No newline at end of file
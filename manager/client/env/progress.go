@@ -0,0 +1,41 @@
+// progress.go
+// NewTerminalProgressFunc is the standard way to plug Options.ProgressFunc
+// into a live progress bar for CLI use, without printing bar escape codes
+// when output isn't a terminal (e.g. piped into a file or running in CI).
+
+package env_client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cheggaaa/pb/v3"
+	"golang.org/x/term"
+)
+
+// NewTerminalProgressFunc returns an Options.ProgressFunc that renders label
+// and a live bar on os.Stderr via cheggaaa/pb, or nil if os.Stderr isn't a
+// terminal. Pass the result straight through as Options.ProgressFunc; a nil
+// ProgressFunc is a no-op there, so no caller-side check is needed.
+func NewTerminalProgressFunc(label string) func(bytesDone, bytesTotal int64) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+
+	var bar *pb.ProgressBar
+	return func(bytesDone, bytesTotal int64) {
+		if bar == nil {
+			tmpl := fmt.Sprintf(`{{string . "label" | green}} {{counters . }} {{bar . }} {{percent . }}`)
+			bar = pb.ProgressBarTemplate(tmpl).Start64(bytesTotal)
+			bar.Set("label", label)
+			bar.SetWriter(os.Stderr)
+		}
+		if bytesTotal > 0 && bar.Total() != bytesTotal {
+			bar.SetTotal(bytesTotal)
+		}
+		bar.SetCurrent(bytesDone)
+		if bytesTotal > 0 && bytesDone >= bytesTotal {
+			bar.Finish()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package env_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ShareBackend stores and serves the payload UploadObfuscatedEnvFile hands a
+// URL out for (GenerateShareLink does not go through it; it always asks the
+// DataForge server for a link). The default, used when
+// Options.ShareBackend is left nil, keeps that payload on the DataForge
+// server itself; operators who'd rather keep it on their own object store
+// set Options.ShareBackend to one of the built-in S3/Azure Blob/GCS backends
+// (or a custom one registered via RegisterShareBackend) instead.
+type ShareBackend interface {
+	// Put uploads content for envID, retrievable for ttl (zero meaning the
+	// backend's own default, if it has one), and returns the URL Get later
+	// retrieves it from.
+	Put(ctx context.Context, envID string, content []byte, ttl time.Duration) (url string, err error)
+
+	// Get retrieves the content previously stored at url.
+	Get(ctx context.Context, url string) ([]byte, error)
+
+	// Revoke makes url permanently unusable ahead of its natural expiry, if
+	// the backend supports doing so.
+	Revoke(ctx context.Context, url string) error
+}
+
+// ShareBackendFactory builds a ShareBackend from its configuration, the way
+// Terraform's backend init map builds a Backend from an HCL block. cfg's
+// keys are backend-specific, e.g. "bucket" and "region" for the S3 backend.
+type ShareBackendFactory func(cfg map[string]any) (ShareBackend, error)
+
+var (
+	shareBackendsMu sync.RWMutex
+	shareBackends   = make(map[string]ShareBackendFactory)
+)
+
+// RegisterShareBackend makes a ShareBackend available under name for
+// NewShareBackend to construct, so third parties can plug in backends
+// (Alibaba OSS, Backblaze B2, ...) without patching this module. The
+// built-in S3, Azure Blob and GCS backends register themselves under "s3",
+// "azblob" and "gs" the same way, from their own init functions.
+func RegisterShareBackend(name string, factory ShareBackendFactory) {
+	shareBackendsMu.Lock()
+	defer shareBackendsMu.Unlock()
+	shareBackends[name] = factory
+}
+
+// NewShareBackend constructs the ShareBackend registered under name, passing
+// it cfg. name is typically the URL scheme operators already associate with
+// the store, e.g. "s3" or "gs".
+func NewShareBackend(name string, cfg map[string]any) (ShareBackend, error) {
+	shareBackendsMu.RLock()
+	factory, ok := shareBackends[name]
+	shareBackendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no share backend registered under %q", name)
+	}
+	return factory(cfg)
+}
+
+// restShareBackend is the default ShareBackend. It stores the payload on the
+// DataForge server itself via ImportEnvFile/GenerateShareLink, exactly as
+// UploadObfuscatedEnvFile did before ShareBackend existed. It isn't
+// registered in the scheme registry above: unlike the object-store backends
+// it isn't independently constructible from a cfg map, since it needs the
+// EnvClient it backs.
+type restShareBackend struct {
+	client *EnvClient
+}
+
+func newRestShareBackend(client *EnvClient) *restShareBackend {
+	return &restShareBackend{client: client}
+}
+
+func (b *restShareBackend) Put(ctx context.Context, envID string, content []byte, ttl time.Duration) (string, error) {
+	if err := b.client.ImportEnvFile(ctx, envID, string(content), true); err != nil {
+		return "", fmt.Errorf("rest share backend: failed to import content: %w", err)
+	}
+	shareLink, err := b.client.GenerateShareLink(ctx, envID)
+	if err != nil {
+		return "", fmt.Errorf("rest share backend: failed to generate share link: %w", err)
+	}
+	return shareLink.URL, nil
+}
+
+// Get downloads from url directly rather than through EnvClient.DownloadFromLink,
+// since that method now delegates to the configured ShareBackend's Get.
+func (b *restShareBackend) Get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("rest share backend: failed to create request: %w", err)
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rest share backend: download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rest share backend: download failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("rest share backend: failed to read download content: %w", err)
+	}
+	return content, nil
+}
+
+func (b *restShareBackend) Revoke(ctx context.Context, url string) error {
+	return fmt.Errorf("rest share backend: links expire on their own TTL and can't be revoked explicitly")
+}
@@ -0,0 +1,167 @@
+// share_backend_azblob.go
+// AzureBlobShareBackend keeps shared payloads as blobs in an Azure Storage
+// container instead of on the DataForge server, handing out SAS URLs in
+// their place. Requires
+// github.com/Azure/azure-sdk-for-go/sdk/storage/azblob as a dependency.
+
+package env_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+func init() {
+	RegisterShareBackend("azblob", func(cfg map[string]any) (ShareBackend, error) {
+		accountURL, _ := cfg["account_url"].(string)
+		if accountURL == "" {
+			return nil, fmt.Errorf("azure blob share backend: \"account_url\" is required")
+		}
+		container, _ := cfg["container"].(string)
+		if container == "" {
+			return nil, fmt.Errorf("azure blob share backend: \"container\" is required")
+		}
+		blobPrefix, _ := cfg["blob_prefix"].(string)
+
+		var defaultTTL time.Duration
+		if seconds, ok := cfg["default_ttl_seconds"].(int); ok {
+			defaultTTL = time.Duration(seconds) * time.Second
+		}
+
+		accountName, _ := cfg["account_name"].(string)
+		if accountName == "" {
+			return nil, fmt.Errorf("azure blob share backend: \"account_name\" is required")
+		}
+		accountKey, _ := cfg["account_key"].(string)
+		if accountKey == "" {
+			return nil, fmt.Errorf("azure blob share backend: \"account_key\" is required")
+		}
+
+		credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+		if err != nil {
+			return nil, fmt.Errorf("azure blob share backend: failed to build shared key credential: %w", err)
+		}
+		client, err := azblob.NewClientWithSharedKeyCredential(accountURL, credential, nil)
+		if err != nil {
+			return nil, fmt.Errorf("azure blob share backend: failed to create client: %w", err)
+		}
+
+		return NewAzureBlobShareBackend(client, credential, container, blobPrefix, defaultTTL), nil
+	})
+}
+
+// azureBlobShareBackend is a ShareBackend backed by an Azure Storage container.
+type azureBlobShareBackend struct {
+	client     *azblob.Client
+	credential *azblob.SharedKeyCredential
+	container  string
+	blobPrefix string
+	defaultTTL time.Duration
+}
+
+// NewAzureBlobShareBackend creates a ShareBackend that stores payloads as
+// blobs in container, optionally namespaced under blobPrefix. The SAS URLs
+// Put returns are signed by client, which must have been created with a
+// shared key credential; credential is kept on the backend but Put does not
+// read it. defaultTTL is used whenever Put is called with ttl <= 0.
+func NewAzureBlobShareBackend(client *azblob.Client, credential *azblob.SharedKeyCredential, container, blobPrefix string, defaultTTL time.Duration) ShareBackend {
+	return &azureBlobShareBackend{
+		client:     client,
+		credential: credential,
+		container:  container,
+		blobPrefix: blobPrefix,
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Put uploads content under a blob name derived from envID and returns a
+// SAS URL valid for ttl (or a.defaultTTL, or 24h, in that order).
+func (a *azureBlobShareBackend) Put(ctx context.Context, envID string, content []byte, ttl time.Duration) (string, error) {
+	blobName := a.blobName(envID)
+
+	if _, err := a.client.UploadBuffer(ctx, a.container, blobName, content, nil); err != nil {
+		return "", fmt.Errorf("azure blob share backend: failed to upload blob: %w", err)
+	}
+
+	if ttl <= 0 {
+		ttl = a.defaultTTL
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	permissions := sas.BlobPermissions{Read: true}
+	signedURL, err := a.client.ServiceClient().
+		NewContainerClient(a.container).
+		NewBlobClient(blobName).
+		GetSASURL(permissions, time.Now().Add(ttl), nil)
+	if err != nil {
+		return "", fmt.Errorf("azure blob share backend: failed to generate SAS URL: %w", err)
+	}
+	return signedURL, nil
+}
+
+// Get downloads the blob a SAS URL from Put points at.
+func (a *azureBlobShareBackend) Get(ctx context.Context, downloadURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure blob share backend: failed to build download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure blob share backend: failed to download blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("azure blob share backend: unexpected status %d downloading blob", resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("azure blob share backend: failed to read blob: %w", err)
+	}
+	return content, nil
+}
+
+// Revoke deletes the blob a SAS URL from Put points at, so it stops
+// resolving even before the SAS URL's own expiry.
+func (a *azureBlobShareBackend) Revoke(ctx context.Context, downloadURL string) error {
+	blobName, err := azureBlobNameFromURL(downloadURL)
+	if err != nil {
+		return fmt.Errorf("azure blob share backend: %w", err)
+	}
+	if _, err := a.client.DeleteBlob(ctx, a.container, blobName, nil); err != nil {
+		return fmt.Errorf("azure blob share backend: failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+func (a *azureBlobShareBackend) blobName(envID string) string {
+	suffix := fmt.Sprintf("%s-%d", envID, time.Now().UnixNano())
+	if a.blobPrefix == "" {
+		return suffix
+	}
+	return strings.TrimSuffix(a.blobPrefix, "/") + "/" + suffix
+}
+
+// azureBlobNameFromURL recovers the blob name from a SAS URL's path, which
+// is of the form "/<container>/<blobName>".
+func azureBlobNameFromURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("URL %q does not look like a container blob URL", rawURL)
+	}
+	return parts[1], nil
+}
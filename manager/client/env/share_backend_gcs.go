@@ -0,0 +1,151 @@
+// share_backend_gcs.go
+// GCSShareBackend keeps shared payloads as objects in a Google Cloud
+// Storage bucket instead of on the DataForge server, handing out signed
+// URLs in their place. Requires cloud.google.com/go/storage as a
+// dependency.
+
+package env_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+func init() {
+	RegisterShareBackend("gs", func(cfg map[string]any) (ShareBackend, error) {
+		bucket, _ := cfg["bucket"].(string)
+		if bucket == "" {
+			return nil, fmt.Errorf("gcs share backend: \"bucket\" is required")
+		}
+		objectPrefix, _ := cfg["object_prefix"].(string)
+
+		var defaultTTL time.Duration
+		if seconds, ok := cfg["default_ttl_seconds"].(int); ok {
+			defaultTTL = time.Duration(seconds) * time.Second
+		}
+
+		client, err := storage.NewClient(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("gcs share backend: failed to create client: %w", err)
+		}
+
+		return NewGCSShareBackend(client, bucket, objectPrefix, defaultTTL), nil
+	})
+}
+
+// gcsShareBackend is a ShareBackend backed by a Google Cloud Storage bucket.
+type gcsShareBackend struct {
+	client       *storage.Client
+	bucket       string
+	objectPrefix string
+	defaultTTL   time.Duration
+}
+
+// NewGCSShareBackend creates a ShareBackend that stores payloads in bucket,
+// optionally namespaced under objectPrefix, using client both to upload
+// objects and to sign their download URLs. defaultTTL is used whenever Put
+// is called with ttl <= 0.
+func NewGCSShareBackend(client *storage.Client, bucket, objectPrefix string, defaultTTL time.Duration) ShareBackend {
+	return &gcsShareBackend{
+		client:       client,
+		bucket:       bucket,
+		objectPrefix: objectPrefix,
+		defaultTTL:   defaultTTL,
+	}
+}
+
+// Put uploads content under an object name derived from envID and returns a
+// signed download URL valid for ttl (or g.defaultTTL, or 24h, in that order).
+func (g *gcsShareBackend) Put(ctx context.Context, envID string, content []byte, ttl time.Duration) (string, error) {
+	object := g.objectName(envID)
+	bucket := g.client.Bucket(g.bucket)
+
+	writer := bucket.Object(object).NewWriter(ctx)
+	if _, err := writer.Write(content); err != nil {
+		writer.Close()
+		return "", fmt.Errorf("gcs share backend: failed to upload object: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("gcs share backend: failed to finalize object: %w", err)
+	}
+
+	if ttl <= 0 {
+		ttl = g.defaultTTL
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	signedURL, err := bucket.SignedURL(object, &storage.SignedURLOptions{
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("gcs share backend: failed to sign download URL: %w", err)
+	}
+	return signedURL, nil
+}
+
+// Get downloads the object a signed URL from Put points at.
+func (g *gcsShareBackend) Get(ctx context.Context, downloadURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("gcs share backend: failed to build download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gcs share backend: failed to download object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gcs share backend: unexpected status %d downloading object", resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("gcs share backend: failed to read object: %w", err)
+	}
+	return content, nil
+}
+
+// Revoke deletes the object a signed URL from Put points at, so it stops
+// resolving even before the signed URL's own expiry.
+func (g *gcsShareBackend) Revoke(ctx context.Context, downloadURL string) error {
+	object, err := gcsObjectFromURL(downloadURL)
+	if err != nil {
+		return fmt.Errorf("gcs share backend: %w", err)
+	}
+	if err := g.client.Bucket(g.bucket).Object(object).Delete(ctx); err != nil {
+		return fmt.Errorf("gcs share backend: failed to delete object: %w", err)
+	}
+	return nil
+}
+
+func (g *gcsShareBackend) objectName(envID string) string {
+	suffix := fmt.Sprintf("%s-%d", envID, time.Now().UnixNano())
+	if g.objectPrefix == "" {
+		return suffix
+	}
+	return strings.TrimSuffix(g.objectPrefix, "/") + "/" + suffix
+}
+
+// gcsObjectFromURL recovers the object name from a signed GCS URL's path,
+// which is of the form "/<bucket>/<object>".
+func gcsObjectFromURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("URL %q does not look like a bucket object URL", rawURL)
+	}
+	return parts[1], nil
+}
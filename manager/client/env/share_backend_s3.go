@@ -0,0 +1,151 @@
+// share_backend_s3.go
+// S3ShareBackend keeps shared payloads as objects in an S3 bucket instead of
+// on the DataForge server, handing out presigned GET URLs in their place.
+// Requires github.com/aws/aws-sdk-go-v2/{config,service/s3} as a dependency.
+
+package env_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func init() {
+	RegisterShareBackend("s3", func(cfg map[string]any) (ShareBackend, error) {
+		bucket, _ := cfg["bucket"].(string)
+		if bucket == "" {
+			return nil, fmt.Errorf("s3 share backend: \"bucket\" is required")
+		}
+		region, _ := cfg["region"].(string)
+		keyPrefix, _ := cfg["key_prefix"].(string)
+
+		var defaultTTL time.Duration
+		if seconds, ok := cfg["default_ttl_seconds"].(int); ok {
+			defaultTTL = time.Duration(seconds) * time.Second
+		}
+
+		awsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("s3 share backend: failed to load AWS config: %w", err)
+		}
+
+		return NewS3ShareBackend(s3.NewFromConfig(awsCfg), bucket, keyPrefix, defaultTTL), nil
+	})
+}
+
+// s3ShareBackend is a ShareBackend backed by an S3 bucket.
+type s3ShareBackend struct {
+	client     *s3.Client
+	presigner  *s3.PresignClient
+	bucket     string
+	keyPrefix  string
+	defaultTTL time.Duration
+}
+
+// NewS3ShareBackend creates a ShareBackend that stores payloads in bucket,
+// optionally namespaced under keyPrefix, using client for both uploads and
+// presigning. defaultTTL is used whenever Put is called with ttl <= 0.
+func NewS3ShareBackend(client *s3.Client, bucket, keyPrefix string, defaultTTL time.Duration) ShareBackend {
+	return &s3ShareBackend{
+		client:     client,
+		presigner:  s3.NewPresignClient(client),
+		bucket:     bucket,
+		keyPrefix:  keyPrefix,
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Put uploads content under a key derived from envID and returns a presigned
+// download URL valid for ttl (or s.defaultTTL, or 24h, in that order).
+func (s *s3ShareBackend) Put(ctx context.Context, envID string, content []byte, ttl time.Duration) (string, error) {
+	key := s.objectKey(envID)
+
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(content),
+	}); err != nil {
+		return "", fmt.Errorf("s3 share backend: failed to upload object: %w", err)
+	}
+
+	if ttl <= 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	presigned, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("s3 share backend: failed to presign download URL: %w", err)
+	}
+	return presigned.URL, nil
+}
+
+// Get downloads the object a presigned URL from Put points at.
+func (s *s3ShareBackend) Get(ctx context.Context, downloadURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("s3 share backend: failed to build download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 share backend: failed to download object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s3 share backend: unexpected status %d downloading object", resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3 share backend: failed to read object: %w", err)
+	}
+	return content, nil
+}
+
+// Revoke deletes the object a presigned URL from Put points at, so it stops
+// resolving even before the presigned URL's own expiry.
+func (s *s3ShareBackend) Revoke(ctx context.Context, downloadURL string) error {
+	key, err := s3KeyFromURL(downloadURL)
+	if err != nil {
+		return fmt.Errorf("s3 share backend: %w", err)
+	}
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return fmt.Errorf("s3 share backend: failed to delete object: %w", err)
+	}
+	return nil
+}
+
+func (s *s3ShareBackend) objectKey(envID string) string {
+	suffix := fmt.Sprintf("%s-%d", envID, time.Now().UnixNano())
+	if s.keyPrefix == "" {
+		return suffix
+	}
+	return strings.TrimSuffix(s.keyPrefix, "/") + "/" + suffix
+}
+
+// s3KeyFromURL recovers the object key from a presigned S3 URL's path.
+func s3KeyFromURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	return strings.TrimPrefix(parsed.Path, "/"), nil
+}
@@ -0,0 +1,177 @@
+// streaming.go
+// Streaming variants of ImportEnvFile/ExportEnvFile for large .env payloads,
+// hitting dedicated /stream/ endpoints built for chunked transfer instead of
+// the JSON/plain-text contract ImportEnvFile/ExportEnvFile use. They
+// send/receive their body via a chunked HTTP request/response instead of
+// marshaling the whole content into a single in-memory string first, and
+// report progress through Options.ProgressFunc as bytes move.
+
+package env_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	rest_client "dataforge/client"
+)
+
+// ImportOptions configures ImportEnvStream.
+type ImportOptions struct {
+	// Overwrite controls whether existing variables with the same key are replaced.
+	Overwrite bool
+
+	// Size is the number of bytes r is expected to yield, if known. It's
+	// only used as the bytesTotal argument to Options.ProgressFunc; leave it
+	// zero if r's length isn't known up front (e.g. a pipe), in which case
+	// ProgressFunc is called with bytesTotal 0 to mean "unknown".
+	Size int64
+}
+
+// ImportEnvStream imports variables from r, which must contain .env
+// formatted content, without buffering it into memory first. Large imports
+// (thousands of variables, multi-megabyte files) should prefer this over
+// ImportEnvFile.
+//
+// Usage example:
+//
+//	f, err := os.Open("huge.env")
+//	if err != nil {
+//	    log.Fatalf("Failed to open file: %v", err)
+//	}
+//	defer f.Close()
+//	info, _ := f.Stat()
+//
+//	err = client.ImportEnvStream(context.Background(), "env_123abc", f, env_client.ImportOptions{
+//	    Overwrite: true,
+//	    Size:      info.Size(),
+//	})
+//
+// Parameters:
+//   - ctx: Context for request cancellation/timeouts
+//   - envID: Environment identifier
+//   - r: Source of .env formatted content
+//   - opts: Overwrite behavior and an optional size hint for progress reporting
+//
+// Returns:
+//   - error: Any error encountered during the import
+func (api *EnvClient) ImportEnvStream(ctx context.Context, envID string, r io.Reader, opts ImportOptions) error {
+	path := fmt.Sprintf("/envs/%s/import/stream/", envID)
+
+	body := r
+	if api.progressFunc != nil {
+		body = newProgressReader(r, opts.Size, api.progressFunc)
+	}
+
+	headers := map[string]string{
+		"X-Overwrite-Existing": fmt.Sprintf("%t", opts.Overwrite),
+	}
+
+	_, err := api.client.UploadFile(ctx, path, body, rest_client.UploadOptions{
+		ContentType: "text/plain; charset=utf-8",
+		Headers:     headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stream .env import: %w", err)
+	}
+	return nil
+}
+
+// ExportEnvStream writes an environment's variables in .env file format to
+// w as they're received, without buffering the whole export into memory
+// first. Large exports should prefer this over ExportEnvFile. Unlike
+// ExportEnvFile, it does not decrypt Options.Crypter-encrypted values:
+// decryption needs the whole line buffered to find the key each value
+// belongs to, which defeats the point of streaming, so ExportEnvStream is
+// meant for values that are either unmasked or left encrypted at rest.
+//
+// Usage example:
+//
+//	f, err := os.Create("huge.env")
+//	if err != nil {
+//	    log.Fatalf("Failed to create file: %v", err)
+//	}
+//	defer f.Close()
+//
+//	written, err := client.ExportEnvStream(context.Background(), "env_123abc", f)
+//	if err != nil {
+//	    log.Fatalf("Failed to export: %v", err)
+//	}
+//	fmt.Printf("Wrote %d bytes\n", written)
+//
+// Parameters:
+//   - ctx: Context for request cancellation/timeouts
+//   - envID: Environment identifier
+//   - w: Destination for the exported .env content
+//
+// Returns:
+//   - int64: Number of bytes written to w
+//   - error: Any error encountered during the export
+func (api *EnvClient) ExportEnvStream(ctx context.Context, envID string, w io.Writer) (int64, error) {
+	path := fmt.Sprintf("/envs/%s/export/stream/", envID)
+
+	counter := &countingWriter{w: w}
+	var dest io.Writer = counter
+	if api.progressFunc != nil {
+		dest = newProgressWriter(counter, api.progressFunc)
+	}
+
+	if _, err := api.client.DownloadFile(ctx, path, dest, rest_client.DownloadOptions{}); err != nil {
+		return counter.n, fmt.Errorf("failed to stream .env export: %w", err)
+	}
+	return counter.n, nil
+}
+
+// countingWriter tracks how many bytes have passed through it, so
+// ExportEnvStream can report its return value without relying on anything
+// rest_client.DownloadFile's Response exposes.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// progressReader wraps r, calling progress with cumulative bytes read
+// against total after every Read.
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	done     int64
+	progress func(bytesDone, bytesTotal int64)
+}
+
+func newProgressReader(r io.Reader, total int64, progress func(int64, int64)) *progressReader {
+	return &progressReader{r: r, total: total, progress: progress}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.done += int64(n)
+	p.progress(p.done, p.total)
+	return n, err
+}
+
+// progressWriter wraps w, calling progress with cumulative bytes written
+// against total (0, since a download's total size isn't known up front)
+// after every Write.
+type progressWriter struct {
+	w        io.Writer
+	done     int64
+	progress func(bytesDone, bytesTotal int64)
+}
+
+func newProgressWriter(w io.Writer, progress func(int64, int64)) *progressWriter {
+	return &progressWriter{w: w, progress: progress}
+}
+
+func (p *progressWriter) Write(buf []byte) (int, error) {
+	n, err := p.w.Write(buf)
+	p.done += int64(n)
+	p.progress(p.done, 0)
+	return n, err
+}